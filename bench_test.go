@@ -108,3 +108,80 @@ func BenchmarkStackFormatting(b *testing.B) {
 	}
 	GlobalE = stackStr
 }
+
+func BenchmarkFrameCache(b *testing.B) {
+	type run struct {
+		stack  int
+		cached bool
+	}
+	runs := []run{
+		{10, true},
+		{10, false},
+		{30, true},
+		{30, false},
+		{60, true},
+		{60, false},
+	}
+
+	var stackStr string
+	for _, r := range runs {
+		part := "cached"
+		if !r.cached {
+			part = "uncached"
+		}
+		name := fmt.Sprintf("%%+v-%s-stack-%d", part, r.stack)
+		b.Run(name, func(b *testing.B) {
+			if !r.cached {
+				defer func(c *symbolCache) { frameCache = c }(frameCache)
+				frameCache = newSymbolCache(0)
+			}
+			err := yesErrors(0, r.stack)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				stackStr = fmt.Sprintf("%+v", err)
+			}
+			b.StopTimer()
+		})
+	}
+	GlobalE = stackStr
+}
+
+// discard compares err, as a caller checking it with Is would, and discards
+// it without printing it.
+func discard(err error) { GlobalE = err == errBench }
+
+var errBench = stderrors.New("bench")
+
+// BenchmarkFormatMessage compares the messages of Errorf, Wrapf and
+// WithMessagef, formatted on first use, with those formatted by fmt.Sprintf
+// before the error is created, for errors which are discarded unprinted and
+// for errors whose message is used.
+func BenchmarkFormatMessage(b *testing.B) {
+	user, attempt := "alice", 3
+	runs := []struct {
+		name string
+		fn   func() error
+	}{
+		{"Errorf", func() error { return Errorf("user %s: attempt %d", user, attempt) }},
+		{"New-Sprintf", func() error { return New(fmt.Sprintf("user %s: attempt %d", user, attempt)) }},
+		{"Wrapf", func() error { return Wrapf(errBench, "user %s: attempt %d", user, attempt) }},
+		{"Wrap-Sprintf", func() error { return Wrap(errBench, fmt.Sprintf("user %s: attempt %d", user, attempt)) }},
+		{"WithMessagef", func() error { return WithMessagef(errBench, "user %s: attempt %d", user, attempt) }},
+		{"WithMessage-Sprintf", func() error { return WithMessage(errBench, fmt.Sprintf("user %s: attempt %d", user, attempt)) }},
+	}
+	for _, r := range runs {
+		b.Run(r.name+"-discarded", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				discard(r.fn())
+			}
+		})
+		b.Run(r.name+"-printed", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				GlobalE = r.fn().Error()
+			}
+		})
+	}
+}
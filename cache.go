@@ -0,0 +1,89 @@
+package errors
+
+import (
+	"runtime"
+	"sync"
+)
+
+// frameCacheSize bounds the number of resolved frames kept by frameCache.
+const frameCacheSize = 4096
+
+// frameCache is shared by every Frame, so that formatting the stack traces of
+// many errors raised from the same places symbolizes each program counter
+// only once.
+var frameCache = newSymbolCache(frameCacheSize)
+
+// unknownFrame is the FrameInfo of a program counter which cannot be resolved.
+var unknownFrame = FrameInfo{Function: "unknown", Name: "unknown", File: "unknown"}
+
+// resolve symbolizes pc, a return address as recorded by runtime.Callers,
+// through runtime.CallersFrames. CallersFrames accounts for inlining and so
+// reports the logical function and line of the call site even when it was
+// inlined into its caller.
+func resolve(pc uintptr) FrameInfo {
+	frames := runtime.CallersFrames([]uintptr{pc})
+	frame, _ := frames.Next()
+	if frame.Function == "" {
+		return unknownFrame
+	}
+	return FrameInfo{
+		Function: frame.Function,
+		Name:     funcname(frame.Function),
+		Package:  pkgname(frame.Function),
+		File:     frame.File,
+		Line:     frame.Line,
+	}
+}
+
+// symbolCache is a concurrency-safe cache of resolved program counters.
+//
+// The cache keeps two generations of at most size entries each. Once the
+// current generation is full it replaces the previous one, which is dropped;
+// entries found in the previous generation are promoted to the current one.
+// Program counters in regular use therefore stay cached while the cache as a
+// whole never holds more than 2*size entries. A cache of size zero resolves
+// every lookup.
+type symbolCache struct {
+	size int
+
+	mu   sync.RWMutex
+	cur  map[uintptr]FrameInfo
+	prev map[uintptr]FrameInfo
+}
+
+func newSymbolCache(size int) *symbolCache {
+	return &symbolCache{
+		size: size,
+		cur:  make(map[uintptr]FrameInfo),
+	}
+}
+
+// lookup returns the FrameInfo of pc, resolving and caching it if needed.
+func (c *symbolCache) lookup(pc uintptr) FrameInfo {
+	if c.size <= 0 {
+		return resolve(pc)
+	}
+
+	c.mu.RLock()
+	info, ok := c.cur[pc]
+	if ok {
+		c.mu.RUnlock()
+		return info
+	}
+	info, ok = c.prev[pc]
+	c.mu.RUnlock()
+	if !ok {
+		info = resolve(pc)
+	}
+
+	c.mu.Lock()
+	if _, cached := c.cur[pc]; !cached {
+		if len(c.cur) >= c.size {
+			c.prev = c.cur
+			c.cur = make(map[uintptr]FrameInfo, c.size)
+		}
+		c.cur[pc] = info
+	}
+	c.mu.Unlock()
+	return info
+}
@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestSymbolCacheLookup(t *testing.T) {
+	c := newSymbolCache(4)
+
+	if got := c.lookup(0); got != unknownFrame {
+		t.Errorf("lookup(0): got %+v, want %+v", got, unknownFrame)
+	}
+
+	pc := uintptr(initpc)
+	want := resolve(pc)
+	if want.Function != "github.com/pkg/errors.init" {
+		t.Fatalf("resolve(initpc): got %q, want %q", want.Function, "github.com/pkg/errors.init")
+	}
+	for i := 0; i < 2; i++ {
+		if got := c.lookup(pc); got != want {
+			t.Errorf("lookup(initpc) #%d: got %+v, want %+v", i+1, got, want)
+		}
+	}
+	if _, ok := c.cur[pc]; !ok {
+		t.Errorf("lookup(initpc): pc was not cached")
+	}
+}
+
+func TestSymbolCacheBounded(t *testing.T) {
+	const size = 8
+	c := newSymbolCache(size)
+
+	var pcs [64]uintptr
+	n := runtime.Callers(0, pcs[:])
+	for i := 0; i < 10; i++ {
+		for _, pc := range pcs[:n] {
+			c.lookup(pc)
+		}
+		// lookups of unknown program counters are cached too.
+		for pc := uintptr(1); pc <= 3*size; pc++ {
+			c.lookup(pc)
+		}
+		if got := len(c.cur) + len(c.prev); got > 2*size {
+			t.Fatalf("cache holds %d entries, want at most %d", got, 2*size)
+		}
+	}
+
+	// the most recent lookup is always in the current generation.
+	c.lookup(uintptr(initpc))
+	if _, ok := c.cur[uintptr(initpc)]; !ok {
+		t.Errorf("lookup(initpc): pc was not cached")
+	}
+}
+
+func TestSymbolCacheDisabled(t *testing.T) {
+	c := newSymbolCache(0)
+	if got, want := c.lookup(uintptr(initpc)), resolve(uintptr(initpc)); got != want {
+		t.Errorf("lookup(initpc): got %+v, want %+v", got, want)
+	}
+	if len(c.cur) != 0 {
+		t.Errorf("lookup(initpc): disabled cache stored %d entries", len(c.cur))
+	}
+}
+
+func TestSymbolCacheConcurrent(t *testing.T) {
+	c := newSymbolCache(2)
+	want := resolve(uintptr(initpc))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.lookup(uintptr(i*100 + j))
+				if got := c.lookup(uintptr(initpc)); got != want {
+					t.Errorf("lookup(initpc): got %+v, want %+v", got, want)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
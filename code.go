@@ -0,0 +1,213 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrorCode classifies an error by what went wrong, independently of its
+// message, so that callers can decide how to handle it. The canonical codes
+// are those of gRPC and carry the same numbers.
+//
+// ErrorCode implements error, so that a code can be matched with Is:
+//
+//	if errors.Is(err, errors.CodeNotFound) {
+//	        // handle specifically
+//	}
+type ErrorCode uint32
+
+const (
+	// CodeOK is the code of a nil error. It is not an error code in its
+	// own right, and WithCode ignores it.
+	CodeOK ErrorCode = iota
+
+	// CodeCanceled means the operation was canceled, typically by its
+	// caller.
+	CodeCanceled
+
+	// CodeUnknown is the code of errors which have none.
+	CodeUnknown
+
+	// CodeInvalidArgument means the caller supplied an invalid argument.
+	CodeInvalidArgument
+
+	// CodeDeadlineExceeded means the operation did not complete before
+	// its deadline.
+	CodeDeadlineExceeded
+
+	// CodeNotFound means a requested entity was not found.
+	CodeNotFound
+
+	// CodeAlreadyExists means an entity the caller tried to create
+	// already exists.
+	CodeAlreadyExists
+
+	// CodePermissionDenied means the caller may not perform the
+	// operation.
+	CodePermissionDenied
+
+	// CodeResourceExhausted means a resource, such as a quota, has run
+	// out.
+	CodeResourceExhausted
+
+	// CodeFailedPrecondition means the system is not in the state the
+	// operation requires.
+	CodeFailedPrecondition
+
+	// CodeAborted means the operation was aborted, typically because of
+	// a concurrency conflict.
+	CodeAborted
+
+	// CodeOutOfRange means the operation was attempted past a valid
+	// range.
+	CodeOutOfRange
+
+	// CodeUnimplemented means the operation is not implemented or
+	// supported.
+	CodeUnimplemented
+
+	// CodeInternal means an invariant of the system was broken.
+	CodeInternal
+
+	// CodeUnavailable means the service is currently unavailable; the
+	// operation may succeed if retried.
+	CodeUnavailable
+
+	// CodeDataLoss means data was lost or corrupted.
+	CodeDataLoss
+
+	// CodeUnauthenticated means the caller could not be authenticated.
+	CodeUnauthenticated
+)
+
+var codeNames = [...]string{
+	CodeOK:                 "OK",
+	CodeCanceled:           "Canceled",
+	CodeUnknown:            "Unknown",
+	CodeInvalidArgument:    "InvalidArgument",
+	CodeDeadlineExceeded:   "DeadlineExceeded",
+	CodeNotFound:           "NotFound",
+	CodeAlreadyExists:      "AlreadyExists",
+	CodePermissionDenied:   "PermissionDenied",
+	CodeResourceExhausted:  "ResourceExhausted",
+	CodeFailedPrecondition: "FailedPrecondition",
+	CodeAborted:            "Aborted",
+	CodeOutOfRange:         "OutOfRange",
+	CodeUnimplemented:      "Unimplemented",
+	CodeInternal:           "Internal",
+	CodeUnavailable:        "Unavailable",
+	CodeDataLoss:           "DataLoss",
+	CodeUnauthenticated:    "Unauthenticated",
+}
+
+func (c ErrorCode) String() string {
+	if int(c) < len(codeNames) {
+		return codeNames[c]
+	}
+	return "ErrorCode(" + strconv.FormatUint(uint64(c), 10) + ")"
+}
+
+// Error returns the name of the code, so that codes may be used as errors.
+func (c ErrorCode) Error() string { return c.String() }
+
+// parseCode returns the ErrorCode named s, as returned by String.
+func parseCode(s string) ErrorCode {
+	for c, name := range codeNames {
+		if name == s {
+			return ErrorCode(c)
+		}
+	}
+	return CodeUnknown
+}
+
+// WithCode annotates err with code. The code is not part of the error's
+// message; it is reported by Code, matched by Is and printed under %+v.
+// If err is nil or code is CodeOK, WithCode returns err.
+func WithCode(err error, code ErrorCode) error {
+	if err == nil || code == CodeOK {
+		return err
+	}
+	return &withCode{
+		cause: err,
+		code:  code,
+	}
+}
+
+type withCode struct {
+	cause error
+	code  ErrorCode
+}
+
+func (w *withCode) Error() string { return errorText(w.cause) }
+func (w *withCode) Cause() error  { return w.cause }
+
+func (w *withCode) layerMessage() (string, bool) { return "", false }
+func (w *withCode) toJSONLayer(l *jsonLayer)     { l.Type = "code" }
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *withCode) Unwrap() error { return w.cause }
+
+// ErrorCode returns the code the error was annotated with.
+func (w *withCode) ErrorCode() ErrorCode { return w.code }
+
+// Is reports whether target is the code the error was annotated with.
+func (w *withCode) Is(target error) bool {
+	c, ok := target.(ErrorCode)
+	return ok && c == w.code
+}
+
+func (w *withCode) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			formatCause(s, w.Cause())
+			io.WriteString(s, "\ncode=")
+			io.WriteString(s, w.code.String())
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
+
+// Code returns the code of the outermost error in err's chain of causes which
+// has one: an error annotated by WithCode, an ErrorCode, or any error value
+// which implements
+//
+//	type coder interface {
+//	        ErrorCode() errors.ErrorCode
+//	}
+//
+// Code returns CodeOK if err is nil, and CodeUnknown if no error in the chain
+// has a code.
+func Code(err error) ErrorCode {
+	if err == nil {
+		return CodeOK
+	}
+	for ; err != nil; err = unwrapOnce(err) {
+		if c, ok := codeOf(err); ok {
+			return c
+		}
+	}
+	return CodeUnknown
+}
+
+// codeOf returns the code err itself carries, if any.
+func codeOf(err error) (ErrorCode, bool) {
+	type coder interface {
+		ErrorCode() ErrorCode
+	}
+
+	switch err := err.(type) {
+	case ErrorCode:
+		return err, true
+	case coder:
+		c := err.ErrorCode()
+		return c, c != CodeOK
+	}
+	return CodeOK, false
+}
@@ -0,0 +1,77 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWithCodeNil(t *testing.T) {
+	if got := WithCode(nil, CodeNotFound); got != nil {
+		t.Errorf("WithCode(nil): got %#v, expected nil", got)
+	}
+	if got := WithCode(io.EOF, CodeOK); got != io.EOF {
+		t.Errorf("WithCode(io.EOF, CodeOK): got %#v, expected io.EOF", got)
+	}
+}
+
+func TestWithCode(t *testing.T) {
+	err := WithCode(io.EOF, CodeUnavailable)
+
+	if got := err.Error(); got != "EOF" {
+		t.Errorf("err.Error(): got %q, want %q", got, "EOF")
+	}
+	if Cause(err) != io.EOF {
+		t.Errorf("Cause(err): got %v, want %v", Cause(err), io.EOF)
+	}
+	if got := fmt.Sprintf("%+v", Wrap(err, "wrapped")); !strings.HasPrefix(got, "EOF\ncode=Unavailable\nwrapped\n") {
+		t.Errorf("%%+v: got %q, want the code after the cause", got)
+	}
+}
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorCode
+	}{
+		{nil, CodeOK},
+		{io.EOF, CodeUnknown},
+		{New("error"), CodeUnknown},
+		{WithCode(io.EOF, CodeNotFound), CodeNotFound},
+		{Wrap(WithCode(io.EOF, CodeNotFound), "wrapped"), CodeNotFound},
+		{WithCode(Wrap(WithCode(io.EOF, CodeNotFound), "wrapped"), CodeInternal), CodeInternal},
+		{WithMessage(CodePermissionDenied, "denied"), CodePermissionDenied},
+		{fmt.Errorf("wrap: %w", WithCode(io.EOF, CodeAborted)), CodeAborted},
+	}
+
+	for i, tt := range tests {
+		if got := Code(tt.err); got != tt.want {
+			t.Errorf("test %d: Code(%v): got %v, want %v", i+1, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestErrorCodeString(t *testing.T) {
+	tests := []struct {
+		code ErrorCode
+		want string
+	}{
+		{CodeOK, "OK"},
+		{CodeNotFound, "NotFound"},
+		{CodeUnauthenticated, "Unauthenticated"},
+		{ErrorCode(99), "ErrorCode(99)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.code.String(); got != tt.want {
+			t.Errorf("ErrorCode(%d).String(): got %q, want %q", tt.code, got, tt.want)
+		}
+		if got := tt.code.Error(); got != tt.want {
+			t.Errorf("ErrorCode(%d).Error(): got %q, want %q", tt.code, got, tt.want)
+		}
+		if got := parseCode(tt.want); got != tt.code && tt.code < 99 {
+			t.Errorf("parseCode(%q): got %v, want %v", tt.want, got, tt.code)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+// A Definition declares a kind of error, whose errors share a message format
+// and, optionally, a code and fields. It replaces an error type declared for
+// the sake of being matched:
+//
+//	var ErrUserNotFound = errors.Define("user %s not found", errors.CodeNotFound)
+//
+//	func lookup(id string) (*User, error) {
+//	        ...
+//	        return nil, ErrUserNotFound.New(id)
+//	}
+//
+//	if errors.Is(err, ErrUserNotFound) {
+//	        // handle specifically
+//	}
+//
+// A Definition is itself an error, whose message is its format, so that it
+// may be passed to Is.
+type Definition struct {
+	format string
+	code   ErrorCode
+	fields Fields
+}
+
+// A DefinitionOption sets a property shared by the errors of a Definition.
+// ErrorCode and Fields are DefinitionOptions: the errors of a Definition
+// with a code have that Code, and those of one with fields have those fields,
+// as if annotated by WithCode and WithFields.
+type DefinitionOption interface {
+	applyTo(d *Definition)
+}
+
+func (c ErrorCode) applyTo(d *Definition) { d.code = c }
+
+func (f Fields) applyTo(d *Definition) {
+	if d.fields == nil {
+		d.fields = make(Fields, len(f))
+	}
+	for k, v := range f {
+		d.fields[k] = v
+	}
+}
+
+// Define returns a Definition of errors whose message is formatted from
+// format, according to the fmt package, and with the properties set by opts.
+func Define(format string, opts ...DefinitionOption) *Definition {
+	d := &Definition{format: format}
+	for _, opt := range opts {
+		opt.applyTo(d)
+	}
+	return d
+}
+
+// Error returns the format of the Definition.
+func (d *Definition) Error() string { return d.format }
+
+// New returns an error of the Definition whose message is formatted from its
+// format and args, as Errorf formats it, when it is first used. New also
+// records the stack trace at the point it was called.
+//
+// Is reports the returned error as matching d, whatever its args.
+func (d *Definition) New(args ...interface{}) error {
+	return &definedError{
+		def:   d,
+		msg:   formatMessage(d.format, args),
+		stack: callers(),
+	}
+}
+
+// definedError is an error of a Definition. It has a message and a stack,
+// but no caller.
+type definedError struct {
+	def *Definition
+	msg errorMessage
+	*stack
+}
+
+func (e *definedError) Error() string { return scrub(e.msg.text()) }
+
+func (e *definedError) layerMessage() (string, bool) { return e.Error(), true }
+func (e *definedError) unredacted() string           { return e.msg.revealed() }
+func (e *definedError) toJSONLayer(l *jsonLayer)     { l.Type = "defined" }
+
+// Fields returns the fields of the error's Definition.
+func (e *definedError) Fields() Fields { return e.def.fields }
+
+// Is reports whether target is the Definition of the error or its code.
+func (e *definedError) Is(target error) bool {
+	if c, ok := target.(ErrorCode); ok {
+		return e.def.code != CodeOK && c == e.def.code
+	}
+	return target == e.def
+}
+
+// ErrorCode returns the code of the error's Definition.
+func (e *definedError) ErrorCode() ErrorCode { return e.def.code }
+
+func (e *definedError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, e.Error())
+			if len(e.def.fields) > 0 {
+				io.WriteString(s, "\n")
+				io.WriteString(s, e.def.fields.String())
+			}
+			if e.def.code != CodeOK {
+				io.WriteString(s, "\ncode=")
+				io.WriteString(s, e.def.code.String())
+			}
+			e.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
@@ -0,0 +1,52 @@
+package errors
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+var errUserNotFound = Define("user %s not found", CodeNotFound, Fields{"kind": "user"})
+
+func TestDefine(t *testing.T) {
+	err := errUserNotFound.New("alice")
+
+	if got, want := err.Error(), "user alice not found"; got != want {
+		t.Errorf("Error(): got %q, want %q", got, want)
+	}
+	if got := Code(Wrap(err, "lookup")); got != CodeNotFound {
+		t.Errorf("Code(): got %v, want %v", got, CodeNotFound)
+	}
+	if got, want := AllFields(WithValue(err, "id", "alice")), (Fields{"kind": "user", "id": "alice"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllFields(): got %v, want %v", got, want)
+	}
+	if got := errUserNotFound.Error(); got != "user %s not found" {
+		t.Errorf("Definition.Error(): got %q, want the format", got)
+	}
+	testFormatRegexp(t, 0, err, "%+v", "user alice not found\n"+
+		"kind=user\n"+
+		"code=NotFound\n"+
+		"github.com/pkg/errors.TestDefine\n"+
+		"\t.+/github.com/pkg/errors/define_test.go:12")
+}
+
+func TestDefineOptions(t *testing.T) {
+	def := Define("plain", Fields{"a": 1}, Fields{"b": 2})
+	err := def.New()
+
+	if got := fmt.Sprintf("%v", err); got != "plain" {
+		t.Errorf("%%v: got %q, want %q", got, "plain")
+	}
+	if got := Code(err); got != CodeUnknown {
+		t.Errorf("Code(): got %v, want %v", got, CodeUnknown)
+	}
+	if got, want := AllFields(err), (Fields{"a": 1, "b": 2}); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllFields(): got %v, want %v", got, want)
+	}
+	if got := Unredacted(errUserNotFound.New(Secret("carol"))); got != "user carol not found" {
+		t.Errorf("Unredacted(): got %q, want %q", got, "user carol not found")
+	}
+	if _, ok := Cause(Wrap(def.New(), "wrapped")).(*definedError); !ok {
+		t.Errorf("Cause(): got %#v, want the defined error", Cause(Wrap(def.New(), "wrapped")))
+	}
+}
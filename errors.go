@@ -62,6 +62,9 @@
 //     %v    see %s
 //     %+v   extended format. Each Frame of the error's StackTrace will
 //           be printed in detail.
+//     %+#v  compact extended format. As %+v, but the frames a stack
+//           shares with the stack of the error it wraps are replaced
+//           by a "... N frames in common" line.
 //
 // Retrieving the stack trace of an error or wrapper
 //
@@ -89,6 +92,10 @@
 // Although the stackTracer interface is not exported by this package, it is
 // considered a part of its stable public interface.
 //
+// How much of the stack is recorded can be tuned with SetStackDepth and
+// SetStackMode, or with the PKG_ERRORS_STACK environment variable, for
+// programs where capturing stacks on hot paths is too costly.
+//
 // See the documentation for Frame.Format for more details.
 package errors
 
@@ -101,7 +108,7 @@ import (
 // New also records the stack trace at the point it was called.
 func New(message string) error {
 	return &fundamental{
-		msg:   message,
+		msg:   errorMessage{msg: message},
 		stack: callers(),
 	}
 }
@@ -109,34 +116,42 @@ func New(message string) error {
 // Errorf formats according to a format specifier and returns the string
 // as a value that satisfies error.
 // Errorf also records the stack trace at the point it was called.
+//
+// The message is formatted when it is first used rather than by Errorf, as
+// many errors are never printed, so the values which args point to should
+// not be changed after Errorf returns.
 func Errorf(format string, args ...interface{}) error {
 	return &fundamental{
-		msg:   fmt.Sprintf(format, args...),
+		msg:   formatMessage(format, args),
 		stack: callers(),
 	}
 }
 
 // fundamental is an error that has a message and a stack, but no caller.
 type fundamental struct {
-	msg string
+	msg errorMessage
 	*stack
 }
 
-func (f *fundamental) Error() string { return f.msg }
+func (f *fundamental) Error() string { return scrub(f.msg.text()) }
+
+func (f *fundamental) layerMessage() (string, bool) { return f.Error(), true }
+func (f *fundamental) unredacted() string           { return f.msg.revealed() }
+func (f *fundamental) toJSONLayer(l *jsonLayer)     { l.Type = "fundamental" }
 
 func (f *fundamental) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		if s.Flag('+') {
-			io.WriteString(s, f.msg)
+			io.WriteString(s, f.Error())
 			f.stack.Format(s, verb)
 			return
 		}
 		fallthrough
 	case 's':
-		io.WriteString(s, f.msg)
+		io.WriteString(s, f.Error())
 	case 'q':
-		fmt.Fprintf(s, "%q", f.msg)
+		fmt.Fprintf(s, "%q", f.Error())
 	}
 }
 
@@ -152,12 +167,45 @@ func WithStack(err error) error {
 	}
 }
 
+// WithStackDepth annotates err with a stack trace at the point WithStackDepth
+// was called, recording at most depth frames regardless of the depth set with
+// SetStackDepth. A depth of zero or less records the whole stack.
+// If err is nil, WithStackDepth returns nil.
+func WithStackDepth(err error, depth int) error {
+	if err == nil {
+		return nil
+	}
+	return &withStack{
+		err,
+		callersDepth(3, depth),
+	}
+}
+
+// WithStackMode annotates err with a stack trace at the point WithStackMode
+// was called, recorded as mode requires regardless of the mode set with
+// SetStackMode. With StackNone the returned error records no frames but
+// otherwise behaves as one returned by WithStack.
+// If err is nil, WithStackMode returns nil.
+func WithStackMode(err error, mode StackMode) error {
+	if err == nil {
+		return nil
+	}
+	return &withStack{
+		err,
+		capture(3, mode, StackDepth()),
+	}
+}
+
 type withStack struct {
 	error
 	*stack
 }
 
-func (w *withStack) Cause() error { return w.error }
+func (w *withStack) Error() string { return errorText(w.error) }
+func (w *withStack) Cause() error  { return w.error }
+
+func (w *withStack) layerMessage() (string, bool) { return "", false }
+func (w *withStack) toJSONLayer(l *jsonLayer)     { l.Type = "stack" }
 
 // Unwrap provides compatibility for Go 1.13 error chains.
 func (w *withStack) Unwrap() error { return w.error }
@@ -166,7 +214,11 @@ func (w *withStack) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		if s.Flag('+') {
-			fmt.Fprintf(s, "%+v", w.Cause())
+			formatCause(s, w.Cause())
+			if s.Flag('#') {
+				w.stack.formatCommon(s, stackOf(w.error))
+				return
+			}
 			w.stack.Format(s, verb)
 			return
 		}
@@ -187,7 +239,7 @@ func Wrap(err error, message string) error {
 	}
 	err = &withMessage{
 		cause: err,
-		msg:   message,
+		msg:   errorMessage{msg: message},
 	}
 	return &withStack{
 		err,
@@ -198,13 +250,74 @@ func Wrap(err error, message string) error {
 // Wrapf returns an error annotating err with a stack trace
 // at the point Wrapf is called, and the format specifier.
 // If err is nil, Wrapf returns nil.
+// The message is formatted when it is first used, as that of Errorf is.
 func Wrapf(err error, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
 	}
 	err = &withMessage{
 		cause: err,
-		msg:   fmt.Sprintf(format, args...),
+		msg:   formatMessage(format, args),
+	}
+	return &withStack{
+		err,
+		callers(),
+	}
+}
+
+// EnsureStack annotates err with a stack trace at the point EnsureStack was
+// called, unless err or one of its causes already records a stack trace, in
+// which case err is returned unchanged.
+// If err is nil, EnsureStack returns nil.
+func EnsureStack(err error) error {
+	if err == nil || hasStack(err) {
+		return err
+	}
+	return &withStack{
+		err,
+		callers(),
+	}
+}
+
+// WrapIfNoStack returns an error annotating err with the supplied message
+// and, unless err or one of its causes already records a stack trace, with a
+// stack trace at the point WrapIfNoStack is called. Wrapping an error from
+// this package therefore keeps its original, deepest trace as the only one.
+// If err is nil, WrapIfNoStack returns nil.
+func WrapIfNoStack(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	stacked := hasStack(err)
+	err = &withMessage{
+		cause: err,
+		msg:   errorMessage{msg: message},
+	}
+	if stacked {
+		return err
+	}
+	return &withStack{
+		err,
+		callers(),
+	}
+}
+
+// WrapfIfNoStack returns an error annotating err with the format specifier
+// and, unless err or one of its causes already records a stack trace, with a
+// stack trace at the point WrapfIfNoStack is called.
+// If err is nil, WrapfIfNoStack returns nil.
+// The message is formatted when it is first used, as that of Errorf is.
+func WrapfIfNoStack(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	stacked := hasStack(err)
+	err = &withMessage{
+		cause: err,
+		msg:   formatMessage(format, args),
+	}
+	if stacked {
+		return err
 	}
 	return &withStack{
 		err,
@@ -220,30 +333,35 @@ func WithMessage(err error, message string) error {
 	}
 	return &withMessage{
 		cause: err,
-		msg:   message,
+		msg:   errorMessage{msg: message},
 	}
 }
 
 // WithMessagef annotates err with the format specifier.
 // If err is nil, WithMessagef returns nil.
+// The message is formatted when it is first used, as that of Errorf is.
 func WithMessagef(err error, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
 	}
 	return &withMessage{
 		cause: err,
-		msg:   fmt.Sprintf(format, args...),
+		msg:   formatMessage(format, args),
 	}
 }
 
 type withMessage struct {
 	cause error
-	msg   string
+	msg   errorMessage
 }
 
-func (w *withMessage) Error() string { return w.msg + ": " + w.cause.Error() }
+func (w *withMessage) Error() string { return scrub(w.msg.text()) + ": " + errorText(w.cause) }
 func (w *withMessage) Cause() error  { return w.cause }
 
+func (w *withMessage) layerMessage() (string, bool) { return scrub(w.msg.text()), true }
+func (w *withMessage) unredacted() string           { return w.msg.revealed() + ": " + Unredacted(w.cause) }
+func (w *withMessage) toJSONLayer(l *jsonLayer)     { l.Type = "message" }
+
 // Unwrap provides compatibility for Go 1.13 error chains.
 func (w *withMessage) Unwrap() error { return w.cause }
 
@@ -251,8 +369,9 @@ func (w *withMessage) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		if s.Flag('+') {
-			fmt.Fprintf(s, "%+v\n", w.Cause())
-			io.WriteString(s, w.msg)
+			formatCause(s, w.Cause())
+			io.WriteString(s, "\n")
+			io.WriteString(s, scrub(w.msg.text()))
 			return
 		}
 		fallthrough
@@ -286,3 +405,99 @@ func Cause(err error) error {
 	}
 	return err
 }
+
+// formatCause writes cause in the extended format.
+func formatCause(s fmt.State, cause error) {
+	io.WriteString(s, sprintCause(s, cause))
+}
+
+// sprintCause returns cause in the extended format, scrubbed by the current
+// Scrubbers if it is not an error of this package, whose messages are
+// scrubbed already.
+func sprintCause(s fmt.State, cause error) string {
+	str := fmt.Sprintf(causeVerb(s, cause), cause)
+	if _, ok := cause.(layerMessager); ok {
+		return str
+	}
+	return scrub(str)
+}
+
+// causeVerb returns the verb with which to print cause in the extended
+// format. The # flag is passed on to causes which implement fmt.Formatter, so
+// that a chain of errors printed with %+#v is compact throughout; other
+// errors are printed with %+v, as their %#v form is Go syntax rather than a
+// message.
+func causeVerb(s fmt.State, cause error) string {
+	if _, ok := cause.(fmt.Formatter); ok && s.Flag('#') {
+		return "%+#v"
+	}
+	return "%+v"
+}
+
+// stackTracer is implemented by errors which record a stack trace.
+type stackTracer interface {
+	StackTrace() StackTrace
+}
+
+// hasStack reports whether err or one of its causes records a stack trace.
+func hasStack(err error) bool {
+	_, ok := tracerOf(err)
+	return ok
+}
+
+// stackOf returns the StackTrace of the outermost error in err's chain of
+// causes which has one, or nil if none does.
+func stackOf(err error) StackTrace {
+	if st, ok := tracerOf(err); ok {
+		return st.StackTrace()
+	}
+	return nil
+}
+
+// tracerOf returns the outermost error in err's chain of causes which
+// records a stack trace.
+func tracerOf(err error) (stackTracer, bool) {
+	for err != nil {
+		if st, ok := err.(stackTracer); ok {
+			return st, true
+		}
+		err = unwrapOnce(err)
+	}
+	return nil, false
+}
+
+// layerMessager is implemented by the errors of this package, to report the
+// message each adds to its chain of causes.
+type layerMessager interface {
+	layerMessage() (string, bool)
+}
+
+// layerMessage returns the message err adds to its chain of causes, if any:
+// the message of those errors from this package which carry one, or the whole
+// message of errors from other packages.
+func layerMessage(err error) (string, bool) {
+	if m, ok := err.(layerMessager); ok {
+		return m.layerMessage()
+	}
+	return scrub(err.Error()), true
+}
+
+// unwrapOnce returns the next error in err's chain of causes: the result of
+// its Cause method or, for errors which have none, of its Unwrap method. It
+// returns nil if err wraps no single error.
+func unwrapOnce(err error) error {
+	type causer interface {
+		Cause() error
+	}
+	type wrapper interface {
+		Unwrap() error
+	}
+
+	switch x := err.(type) {
+	case causer:
+		return x.Cause()
+	case wrapper:
+		return x.Unwrap()
+	}
+	return nil
+}
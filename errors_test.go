@@ -249,3 +249,76 @@ func TestErrorEquality(t *testing.T) {
 		}
 	}
 }
+
+func TestEnsureStack(t *testing.T) {
+	stacked := New("error")
+	tests := []struct {
+		err     error
+		wrapped bool
+	}{
+		{io.EOF, true},
+		{stacked, false},
+		{WithMessage(stacked, "message"), false},
+		{fmt.Errorf("wrap: %w", stacked), false},
+	}
+
+	for i, tt := range tests {
+		got := EnsureStack(tt.err)
+		if (got != tt.err) != tt.wrapped {
+			t.Errorf("test %d: EnsureStack(%v): got %#v, wrapped should be %t", i+1, tt.err, got, tt.wrapped)
+		}
+		if Cause(got) != Cause(tt.err) {
+			t.Errorf("test %d: Cause(EnsureStack(%v)): got %v, want %v", i+1, tt.err, Cause(got), Cause(tt.err))
+		}
+	}
+
+	if got := EnsureStack(nil); got != nil {
+		t.Errorf("EnsureStack(nil): got %#v, expected nil", got)
+	}
+}
+
+func TestWrapIfNoStack(t *testing.T) {
+	stacked := New("error")
+	inner := stacked.(stackTracer).StackTrace()
+	tests := []struct {
+		err   error
+		want  string
+		stack bool
+	}{
+		{io.EOF, "message: EOF", true},
+		{stacked, "message: error", false},
+		{Wrap(io.EOF, "wrapped"), "message: wrapped: EOF", false},
+	}
+
+	for i, tt := range tests {
+		for _, got := range []error{
+			WrapIfNoStack(tt.err, "message"),
+			WrapfIfNoStack(tt.err, "%s", "message"),
+		} {
+			if got.Error() != tt.want {
+				t.Errorf("test %d: got %q, want %q", i+1, got.Error(), tt.want)
+			}
+			if Cause(got) != Cause(tt.err) {
+				t.Errorf("test %d: Cause: got %v, want %v", i+1, Cause(got), Cause(tt.err))
+			}
+			if _, ok := got.(stackTracer); ok != tt.stack {
+				t.Errorf("test %d: %#v records a stack: %t, want %t", i+1, got, ok, tt.stack)
+			}
+		}
+	}
+
+	got := stackOf(WrapIfNoStack(stacked, "message"))
+	if !reflect.DeepEqual(got, inner) {
+		t.Errorf("WrapIfNoStack(stacked) did not keep the original stack:\n got %v\nwant %v", got, inner)
+	}
+	if n := testing.AllocsPerRun(10, func() { WrapIfNoStack(stacked, "message") }); n != 1 {
+		t.Errorf("WrapIfNoStack(stacked): got %v allocations, want 1", n)
+	}
+
+	if got := WrapIfNoStack(nil, "message"); got != nil {
+		t.Errorf("WrapIfNoStack(nil): got %#v, expected nil", got)
+	}
+	if got := WrapfIfNoStack(nil, "message"); got != nil {
+		t.Errorf("WrapfIfNoStack(nil): got %#v, expected nil", got)
+	}
+}
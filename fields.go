@@ -0,0 +1,158 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Fields are key/value pairs which describe the context of an error, such as
+// the request or user it occurred for.
+type Fields map[string]interface{}
+
+// WithFields annotates err with fields. The fields are not part of the error's
+// message; they are reported by AllFields and printed under %+v.
+// If err is nil, WithFields returns nil.
+func WithFields(err error, fields Fields) error {
+	if err == nil {
+		return nil
+	}
+	f := make(Fields, len(fields))
+	for k, v := range fields {
+		f[k] = v
+	}
+	return &withFields{
+		cause:  err,
+		fields: f,
+	}
+}
+
+// WithValue annotates err with the field key set to value.
+// If err is nil, WithValue returns nil.
+func WithValue(err error, key string, value interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &withFields{
+		cause:  err,
+		fields: Fields{key: value},
+	}
+}
+
+type withFields struct {
+	cause  error
+	fields Fields
+}
+
+func (w *withFields) Error() string { return errorText(w.cause) }
+func (w *withFields) Cause() error  { return w.cause }
+
+// Fields returns the fields the error was annotated with.
+func (w *withFields) Fields() Fields { return w.fields }
+
+func (w *withFields) layerMessage() (string, bool) { return "", false }
+func (w *withFields) toJSONLayer(l *jsonLayer)     { l.Type = "fields" }
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *withFields) Unwrap() error { return w.cause }
+
+func (w *withFields) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			formatCause(s, w.Cause())
+			io.WriteString(s, "\n")
+			io.WriteString(s, w.fields.String())
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
+
+// String formats the fields as space separated key=value pairs, sorted by
+// key. Values are formatted with %v, scrubbed by the current Scrubbers, and
+// quoted if they contain spaces.
+func (f Fields) String() string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		v := scrub(fmt.Sprint(f[k]))
+		if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+			v = fmt.Sprintf("%q", v)
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(v)
+	}
+	return b.String()
+}
+
+// AllFields returns the fields of every error in err's chain of causes,
+// merged into one set. Fields are set by WithFields, WithValue and Define,
+// and by any error value which implements
+//
+//	type fielder interface {
+//	        Fields() errors.Fields
+//	}
+//
+// Where several errors set the same key, the value of the outermost one, the
+// one added last, wins. AllFields returns nil if no error in the chain has
+// fields.
+func AllFields(err error) Fields {
+	var layers []Fields
+	for err != nil {
+		if fields := fieldsOf(err); fields != nil {
+			layers = append(layers, fields)
+		}
+		err = unwrapOnce(err)
+	}
+	if len(layers) == 0 {
+		return nil
+	}
+
+	all := make(Fields)
+	for i := len(layers) - 1; i >= 0; i-- {
+		for k, v := range layers[i] {
+			all[k] = v
+		}
+	}
+	return all
+}
+
+// Value returns the value of the field key of the outermost error in err's
+// chain of causes which sets it.
+func Value(err error, key string) (interface{}, bool) {
+	for err != nil {
+		if v, ok := fieldsOf(err)[key]; ok {
+			return v, true
+		}
+		err = unwrapOnce(err)
+	}
+	return nil, false
+}
+
+// fielder is implemented by errors which set fields.
+type fielder interface {
+	Fields() Fields
+}
+
+// fieldsOf returns the fields err itself sets, if any.
+func fieldsOf(err error) Fields {
+	if f, ok := err.(fielder); ok {
+		return f.Fields()
+	}
+	return nil
+}
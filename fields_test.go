@@ -0,0 +1,141 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestWithFieldsNil(t *testing.T) {
+	if got := WithFields(nil, Fields{"k": "v"}); got != nil {
+		t.Errorf("WithFields(nil): got %#v, expected nil", got)
+	}
+	if got := WithValue(nil, "k", "v"); got != nil {
+		t.Errorf("WithValue(nil): got %#v, expected nil", got)
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	fields := Fields{"user_id": 42}
+	err := WithFields(io.EOF, fields)
+	fields["user_id"] = 43
+
+	if got := err.Error(); got != "EOF" {
+		t.Errorf("err.Error(): got %q, want %q", got, "EOF")
+	}
+	if Cause(err) != io.EOF {
+		t.Errorf("Cause(err): got %v, want %v", Cause(err), io.EOF)
+	}
+	if got, want := AllFields(err), (Fields{"user_id": 42}); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllFields(err): got %v, want %v", got, want)
+	}
+}
+
+func TestAllFields(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Fields
+	}{
+		{nil, nil},
+		{io.EOF, nil},
+		{WithValue(io.EOF, "path", "/tmp"), Fields{"path": "/tmp"}},
+		{
+			WithFields(Wrap(WithFields(io.EOF, Fields{"a": 1, "b": 1}), "wrapped"), Fields{"b": 2, "c": 2}),
+			Fields{"a": 1, "b": 2, "c": 2},
+		},
+		{
+			WithValue(fmt.Errorf("wrap: %w", WithValue(io.EOF, "a", 1)), "a", 2),
+			Fields{"a": 2},
+		},
+	}
+
+	for i, tt := range tests {
+		if got := AllFields(tt.err); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("test %d: AllFields(%v): got %v, want %v", i+1, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	err := WithValue(Wrap(WithFields(io.EOF, Fields{"a": 1, "b": 1}), "wrapped"), "b", 2)
+
+	tests := []struct {
+		key  string
+		want interface{}
+		ok   bool
+	}{
+		{"a", 1, true},
+		{"b", 2, true},
+		{"c", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Value(err, tt.key)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Value(err, %q): got %v, %t, want %v, %t", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFormatWithFields(t *testing.T) {
+	tests := []struct {
+		error
+		format string
+		want   []string
+	}{{
+		WithValue(io.EOF, "user_id", 42),
+		"%s",
+		[]string{"EOF"},
+	}, {
+		WithValue(io.EOF, "user_id", 42),
+		"%v",
+		[]string{"EOF"},
+	}, {
+		WithValue(io.EOF, "user_id", 42),
+		"%q",
+		[]string{`"EOF"`},
+	}, {
+		WithFields(Wrap(io.EOF, "read failed"), Fields{"path": "/tmp/a b", "user_id": 42}),
+		"%+v",
+		[]string{
+			"EOF",
+			"read failed",
+			"github.com/pkg/errors.TestFormatWithFields\n" +
+				"\t.+/github.com/pkg/errors/fields_test.go:99",
+			`path="/tmp/a b" user_id=42`},
+	}}
+
+	for i, tt := range tests {
+		testFormatCompleteCompare(t, i, tt.error, tt.format, tt.want, true)
+	}
+}
+
+func TestFieldsString(t *testing.T) {
+	tests := []struct {
+		fields Fields
+		want   string
+	}{
+		{nil, ""},
+		{Fields{"b": 2, "a": "x"}, "a=x b=2"},
+		{Fields{"empty": "", "eq": "a=b", "quote": `"`}, `empty="" eq="a=b" quote="\""`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.fields.String(); got != tt.want {
+			t.Errorf("%#v.String(): got %q, want %q", tt.fields, got, tt.want)
+		}
+	}
+}
+
+// fieldsError is an error from another package which sets fields.
+type fieldsError struct{ error }
+
+func (e fieldsError) Fields() Fields { return Fields{"b": 3} }
+
+func TestAllFieldsFielder(t *testing.T) {
+	err := WithValue(fmt.Errorf("wrap: %w", fieldsError{io.EOF}), "a", 1)
+	if got, want := AllFields(err), (Fields{"a": 1, "b": 3}); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllFields(err): got %v, want %v", got, want)
+	}
+}
@@ -558,3 +558,54 @@ func testGenericRecursive(t *testing.T, beforeErr error, beforeWant []string, li
 		}
 	}
 }
+
+func compactNew() error { return New("error") }
+
+func TestFormatCompact(t *testing.T) {
+	tests := []struct {
+		error
+		want string
+	}{{
+		Wrap(compactNew(), "wrapped"),
+		"^error\n" +
+			"github.com/pkg/errors.compactNew\n" +
+			"\t.+/github.com/pkg/errors/format_test.go:562\n" +
+			"github.com/pkg/errors.TestFormatCompact\n" +
+			"\t.+/github.com/pkg/errors/format_test.go:569\n" +
+			"(?s:.*)" +
+			"wrapped\n" +
+			"github.com/pkg/errors.TestFormatCompact\n" +
+			"\t.+/github.com/pkg/errors/format_test.go:569\n" +
+			`\.\.\. \d+ frames in common$`,
+	}, {
+		WithStack(WithStack(io.EOF)),
+		"^EOF\n" +
+			"github.com/pkg/errors.TestFormatCompact\n" +
+			"\t.+/github.com/pkg/errors/format_test.go:581\n" +
+			"(?s:.*)" +
+			`\.\.\. \d+ frames in common$`,
+	}, {
+		WithMessage(Wrap(io.EOF, "wrapped"), "message"),
+		"^EOF\n" +
+			"wrapped\n" +
+			"github.com/pkg/errors.TestFormatCompact\n" +
+			"\t.+/github.com/pkg/errors/format_test.go:588\n" +
+			"(?s:.*)" +
+			"message$",
+	}}
+
+	for i, tt := range tests {
+		got := fmt.Sprintf("%+#v", tt.error)
+		if !regexp.MustCompile(tt.want).MatchString(got) {
+			t.Errorf("test %d: fmt.Sprintf(%%+#v, err):\n got: %q\nwant: %q", i+1, got, tt.want)
+		}
+		// Frames below the test function are printed once, by the
+		// innermost stack, rather than by every stack in the chain.
+		if n := strings.Count(got, "testing.tRunner"); n != 1 {
+			t.Errorf("test %d: fmt.Sprintf(%%+#v, err): testing.tRunner printed %d times, want once:\n%s", i+1, n, got)
+		}
+		if n := strings.Count(fmt.Sprintf("%+v", tt.error), "testing.tRunner"); n != strings.Count(got, "\n... ")+1 {
+			t.Errorf("test %d: fmt.Sprintf(%%+v, err): testing.tRunner printed %d times", i+1, n)
+		}
+	}
+}
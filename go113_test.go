@@ -5,6 +5,7 @@ package errors
 import (
 	stderrors "errors"
 	"fmt"
+	"io"
 	"reflect"
 	"testing"
 )
@@ -176,3 +177,57 @@ func TestUnwrap(t *testing.T) {
 		})
 	}
 }
+
+func TestWithCodeIs(t *testing.T) {
+	err := Wrap(WithCode(io.EOF, CodeNotFound), "wrapped")
+
+	if !Is(err, CodeNotFound) {
+		t.Errorf("Is(err, CodeNotFound): got false, want true")
+	}
+	if Is(err, CodeInternal) {
+		t.Errorf("Is(err, CodeInternal): got true, want false")
+	}
+	if !Is(err, io.EOF) {
+		t.Errorf("Is(err, io.EOF): got false, want true")
+	}
+
+	var decoded error
+	data, _ := err.(*withStack).MarshalJSON()
+	if e := UnmarshalError(data, &decoded); e != nil {
+		t.Fatal(e)
+	}
+	if !Is(decoded, CodeNotFound) || Code(decoded) != CodeNotFound {
+		t.Errorf("Is(decoded, CodeNotFound): got false, want true")
+	}
+}
+
+func TestRaiseIs(t *testing.T) {
+	errA, errB := Sentinel("sentinel"), Sentinel("sentinel")
+	err := Wrap(Raise(errA), "wrapped")
+
+	if !Is(err, errA) {
+		t.Errorf("Is(err, errA): got false, want true")
+	}
+	if Is(err, errB) {
+		t.Errorf("Is(err, errB): got true, want false")
+	}
+}
+
+func TestDefineIs(t *testing.T) {
+	errA := Define("not found: %s", CodeNotFound)
+	errB := Define("not found: %s")
+	err := Wrap(errA.New("a"), "lookup")
+
+	if !Is(err, errA) {
+		t.Errorf("Is(err, errA): got false, want true")
+	}
+	if Is(err, errB) {
+		t.Errorf("Is(err, errB): got true, want false")
+	}
+	if !Is(err, CodeNotFound) {
+		t.Errorf("Is(err, CodeNotFound): got false, want true")
+	}
+	if Is(errB.New("b"), CodeOK) {
+		t.Errorf("Is(errB.New(), CodeOK): got true, want false")
+	}
+}
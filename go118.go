@@ -0,0 +1,50 @@
+//go:build go1.18
+// +build go1.18
+
+package errors
+
+// AsType finds the first error in err's tree of causes, in the order in which
+// Walk visits them, which is of type T, and returns it. T is typically a
+// pointer to an error type, or an interface:
+//
+//	if perr, ok := errors.AsType[*fs.PathError](err); ok {
+//	        fmt.Println(perr.Path)
+//	}
+//
+// As with As, an error which is not of type T matches if it has a method
+// As(interface{}) bool which, passed a pointer to a T, returns true, in which
+// case the T it set is returned.
+func AsType[T any](err error) (T, bool) {
+	var target T
+	found := false
+	Walk(err, func(err error) bool {
+		target, found = asType[T](err)
+		return !found
+	})
+	return target, found
+}
+
+// FindAll returns every error in err's tree of causes which is of type T, or
+// matches T as AsType describes, in the order in which Walk visits them.
+func FindAll[T any](err error) []T {
+	var all []T
+	Walk(err, func(err error) bool {
+		if t, ok := asType[T](err); ok {
+			all = append(all, t)
+		}
+		return true
+	})
+	return all
+}
+
+// asType returns err as a T, if it is one or its As method converts it to one.
+func asType[T any](err error) (T, bool) {
+	if t, ok := err.(T); ok {
+		return t, true
+	}
+	var t T
+	if x, ok := err.(interface{ As(interface{}) bool }); ok && x.As(&t) {
+		return t, true
+	}
+	return t, false
+}
@@ -0,0 +1,62 @@
+//go:build go1.18
+// +build go1.18
+
+package errors
+
+import (
+	"io"
+	"io/fs"
+	"testing"
+)
+
+func TestAsType(t *testing.T) {
+	perr := &fs.PathError{Op: "open", Path: "/etc/app.yaml", Err: fs.ErrPermission}
+	err := Wrap(Join(io.EOF, WithMessage(perr, "read config")), "load")
+
+	if got, ok := AsType[*fs.PathError](err); !ok || got != perr {
+		t.Errorf("AsType[*fs.PathError](): got %v, %t, want %v", got, ok, perr)
+	}
+	if got, ok := AsType[interface{ Timeout() bool }](err); !ok || got != perr {
+		t.Errorf("AsType[timeout](): got %v, %t, want %v", got, ok, perr)
+	}
+	if got, ok := AsType[customErr](err); ok {
+		t.Errorf("AsType[customErr](): got %v, want none", got)
+	}
+	if got, ok := AsType[customErr](Wrap(customErr{msg: "custom"}, "wrapped")); !ok || got.msg != "custom" {
+		t.Errorf("AsType[customErr](): got %v, %t, want custom", got, ok)
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	err := Join(WithCode(io.EOF, CodeNotFound), Wrap(WithCode(io.EOF, CodeInternal), "wrapped"))
+
+	codes := FindAll[interface{ ErrorCode() ErrorCode }](err)
+	if len(codes) != 2 || codes[0].ErrorCode() != CodeNotFound || codes[1].ErrorCode() != CodeInternal {
+		t.Errorf("FindAll[coder](): got %v, want the two coded errors", codes)
+	}
+	if got := FindAll[*fs.PathError](err); got != nil {
+		t.Errorf("FindAll[*fs.PathError](): got %v, want nil", got)
+	}
+}
+
+type asTarget struct{}
+
+func (asTarget) Error() string { return "as target" }
+
+// asError converts itself to an asTarget with its As method.
+type asError struct{}
+
+func (asError) Error() string { return "as error" }
+func (asError) As(target interface{}) bool {
+	if p, ok := target.(*asTarget); ok {
+		*p = asTarget{}
+		return true
+	}
+	return false
+}
+
+func TestAsTypeAsMethod(t *testing.T) {
+	if _, ok := AsType[asTarget](Wrap(asError{}, "wrapped")); !ok {
+		t.Errorf("AsType[asTarget](): got false, want the error's As method to convert it")
+	}
+}
@@ -0,0 +1,25 @@
+//go:build go1.20
+// +build go1.20
+
+package errors
+
+import (
+	"io"
+	"testing"
+)
+
+func TestJoinIsAs(t *testing.T) {
+	err := Wrap(Join(WithStack(io.EOF), customErr{msg: "custom"}), "wrapped")
+
+	if !Is(err, io.EOF) {
+		t.Errorf("Is(err, io.EOF): got false, want true")
+	}
+	if Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Is(err, io.ErrUnexpectedEOF): got true, want false")
+	}
+
+	var target customErr
+	if !As(err, &target) || target.msg != "custom" {
+		t.Errorf("As(err, &target): got %v, want %v", target, customErr{msg: "custom"})
+	}
+}
@@ -0,0 +1,171 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Go calls fn in a new goroutine and returns a channel which receives the
+// error it returns, or nil, and is then closed. A panic in fn is recovered
+// and received as the error FromPanic returns for it.
+//
+// Go records the stack trace at the point it was called. Errors received
+// from the channel are annotated with it, so that under %+v they print as
+// they would otherwise, with "failed at:" in front of the trace of where they
+// occurred, if they record one, followed by the trace of where the goroutine
+// was started, after "started at:".
+func Go(fn func() error) <-chan error {
+	ch := make(chan error, 1)
+	spawn := callers()
+	go func() {
+		defer close(ch)
+		ch <- run(fn, spawn)
+	}()
+	return ch
+}
+
+// Group is a collection of goroutines started with its Go method, whose
+// errors are collected by Wait. The zero value is ready to use.
+type Group struct {
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+// Go calls fn in a new goroutine of the group. The error it returns, or the
+// panic it raises, is recorded as those received from the package level Go
+// function are.
+func (g *Group) Go(fn func() error) {
+	spawn := callers()
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := run(fn, spawn); err != nil {
+			g.mu.Lock()
+			g.errs = append(g.errs, err)
+			g.mu.Unlock()
+		}
+	}()
+}
+
+// Wait waits for every goroutine of the group to return and returns the
+// errors they returned, in the order in which they did, combined as Join
+// combines them.
+// If none returned an error, Wait returns nil.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.errs) == 0 {
+		return nil
+	}
+	return &multiError{
+		errs:  append([]error(nil), g.errs...),
+		stack: callers(),
+	}
+}
+
+// run calls fn, recovering a panic in it, and annotates the error it returns
+// with spawn, the stack trace of where the goroutine calling run was started.
+func run(fn func() error, spawn *stack) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = &withSpawn{FromPanic(p), spawn}
+		}
+	}()
+	if err := fn(); err != nil {
+		return &withSpawn{err, spawn}
+	}
+	return nil
+}
+
+// withSpawn is an error returned by a goroutine, annotated with the stack
+// trace of where the goroutine was started.
+type withSpawn struct {
+	cause error
+	spawn *stack
+}
+
+func (w *withSpawn) Error() string { return errorText(w.cause) }
+func (w *withSpawn) Cause() error  { return w.cause }
+
+func (w *withSpawn) layerMessage() (string, bool) { return "", false }
+
+func (w *withSpawn) toJSONLayer(l *jsonLayer) {
+	l.Type = "spawn"
+	l.Stack, l.MoreFrames = w.spawn.frameInfos()
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *withSpawn) Unwrap() error { return w.cause }
+
+func (w *withSpawn) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			frames, more := w.spawn.frameInfos()
+			formatSpawn(s, w.cause, frames, more)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
+
+// formatSpawn writes the extended format of an error returned by a
+// goroutine: cause in the extended format, with "failed at:" in front of the
+// innermost stack trace in its chain of causes, if any, followed by spawn, the
+// stack trace of where the goroutine was started, of which spawnMore frames
+// were not recorded.
+func formatSpawn(s fmt.State, cause error, spawn []FrameInfo, spawnMore int) {
+	str := sprintCause(s, cause)
+	if f, ok := innermostFrame(cause); ok {
+		at := "\n" + f.Function + "\n\t" + f.File + ":" + strconv.Itoa(f.Line)
+		if i := strings.Index(str, at); i >= 0 {
+			str = str[:i] + "\nfailed at:" + str[i:]
+		}
+	}
+	io.WriteString(s, str)
+	io.WriteString(s, "\nstarted at:")
+	formatFrames(s, spawn, spawnMore)
+}
+
+// formatFrames writes frames, of which more were not recorded, as a stack
+// formats itself under %+v.
+func formatFrames(s fmt.State, frames []FrameInfo, more int) {
+	for _, info := range frames {
+		io.WriteString(s, "\n")
+		info.formatFile(s)
+		io.WriteString(s, ":")
+		io.WriteString(s, strconv.Itoa(info.Line))
+	}
+	if more > 0 {
+		fmt.Fprintf(s, "\n... %d more frames", more)
+	}
+}
+
+// innermostFrame returns the first frame of the innermost stack trace in
+// err's chain of causes, where the error occurred.
+func innermostFrame(err error) (FrameInfo, bool) {
+	var frame FrameInfo
+	found := false
+	for ; err != nil; err = unwrapOnce(err) {
+		switch err := err.(type) {
+		case framer:
+			if frames, _ := err.frameInfos(); len(frames) > 0 {
+				frame, found = frames[0], true
+			}
+		case stackTracer:
+			if st := err.StackTrace(); len(st) > 0 {
+				frame, found = st[0].Info(), true
+			}
+		}
+	}
+	return frame, found
+}
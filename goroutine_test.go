@@ -0,0 +1,114 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+)
+
+func TestGo(t *testing.T) {
+	if err := <-Go(func() error { return nil }); err != nil {
+		t.Errorf("Go(nil): got %v, want nil", err)
+	}
+
+	tests := []struct {
+		fn     func() error
+		msg    string
+		format string
+	}{{
+		func() error { return New("failure") },
+		"failure",
+		"failure\n" +
+			"failed at:\n" +
+			"github.com/pkg/errors.TestGo.func2\n" +
+			"\t.+/github.com/pkg/errors/goroutine_test.go:20\n" +
+			"(?s:.*)" +
+			"started at:\n" +
+			"github.com/pkg/errors.TestGo\n" +
+			"\t.+/github.com/pkg/errors/goroutine_test.go:51\n",
+	}, {
+		func() error { panic("boom") },
+		"panic: boom",
+		"panic: boom\n" +
+			"failed at:\n" +
+			"github.com/pkg/errors.TestGo.func3\n" +
+			"\t.+/github.com/pkg/errors/goroutine_test.go:31\n" +
+			"(?s:.*)" +
+			"started at:\n" +
+			"github.com/pkg/errors.TestGo\n" +
+			"\t.+/github.com/pkg/errors/goroutine_test.go:51\n",
+	}, {
+		func() error { return io.EOF },
+		"EOF",
+		"EOF\n" +
+			"started at:\n" +
+			"github.com/pkg/errors.TestGo\n" +
+			"\t.+/github.com/pkg/errors/goroutine_test.go:51\n",
+	}}
+
+	for i, tt := range tests {
+		err := <-Go(tt.fn)
+		if err == nil {
+			t.Fatalf("test %d: Go(): got nil, want error", i+1)
+		}
+		if got := err.Error(); got != tt.msg {
+			t.Errorf("test %d: Error(): got %q, want %q", i+1, got, tt.msg)
+		}
+		if got := fmt.Sprintf("%+v", err); !regexp.MustCompile("^" + tt.format).MatchString(got) {
+			t.Errorf("test %d: %%+v:\n got: %q\nwant: %q", i+1, got, tt.format)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	var g Group
+	if err := g.Wait(); err != nil {
+		t.Errorf("Group.Wait(): got %v, want nil", err)
+	}
+
+	g.Go(func() error { return io.EOF })
+	g.Go(func() error { return nil })
+	g.Go(func() error { panic(io.ErrUnexpectedEOF) })
+	err := g.Wait()
+
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	if len(errs) != 2 {
+		t.Fatalf("Group.Wait().Unwrap(): got %v, want two errors", errs)
+	}
+	for _, e := range errs {
+		if c := Cause(e); c != io.EOF && c != io.ErrUnexpectedEOF {
+			t.Errorf("Cause(%v): got %v, want io.EOF or io.ErrUnexpectedEOF", e, c)
+		}
+		want := "\nstarted at:\n" +
+			"github.com/pkg/errors.TestGroup\n" +
+			"\t.+/github.com/pkg/errors/goroutine_test.go:(70|72)\n"
+		if got := fmt.Sprintf("%+v", e); !regexp.MustCompile(want).MatchString(got) {
+			t.Errorf("%%+v:\n got: %q\nwant: %q", got, want)
+		}
+	}
+}
+
+func TestGoFormatLayers(t *testing.T) {
+	err := <-Go(func() error {
+		return WithFields(Wrap(WithCode(New("inner"), CodeNotFound), "outer"), Fields{"k": "v"})
+	})
+
+	want := "^inner\n" +
+		"failed at:\n" +
+		"github.com/pkg/errors.TestGoFormatLayers.func1\n" +
+		"\t.+/github.com/pkg/errors/goroutine_test.go:94\n" +
+		"(?s:.*)" +
+		"\ncode=NotFound\n" +
+		"outer\n" +
+		"github.com/pkg/errors.TestGoFormatLayers.func1\n" +
+		"\t.+/github.com/pkg/errors/goroutine_test.go:94\n" +
+		"(?s:.*)" +
+		"\nk=v\n" +
+		"started at:\n" +
+		"github.com/pkg/errors.TestGoFormatLayers\n" +
+		"\t.+/github.com/pkg/errors/goroutine_test.go:93\n"
+	if got := fmt.Sprintf("%+v", err); !regexp.MustCompile(want).MatchString(got) {
+		t.Errorf("%%+v:\n got: %q\nwant: %q", got, want)
+	}
+}
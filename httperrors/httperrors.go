@@ -0,0 +1,261 @@
+// Package httperrors reports the errors of package github.com/pkg/errors to
+// HTTP clients.
+//
+// Status maps an error to an HTTP status, from its Code or from statuses
+// registered with RegisterStatus, and WriteProblem writes it as an RFC 9457
+// application/problem+json response, sending only its public message and
+// logging the rest. HandlerFunc and Recoverer adapt handlers to report the
+// errors they return and the panics they raise that way.
+//
+// It is a separate package so that programs which only create errors do not
+// link net/http.
+package httperrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// statuses holds the statuses registered with RegisterStatus.
+var statuses = struct {
+	sync.RWMutex
+	byErr map[error]int
+}{byErr: make(map[error]int)}
+
+// RegisterStatus records status as the HTTP status of err, typically a
+// package level sentinel error such as sql.ErrNoRows, for Status to report
+// for chains which contain it.
+//
+// Registering an error again replaces its status. RegisterStatus panics if
+// err is nil or of a type whose values cannot be compared.
+func RegisterStatus(err error, status int) {
+	if err == nil || !reflect.TypeOf(err).Comparable() {
+		panic("httperrors: RegisterStatus of nil or incomparable error")
+	}
+	statuses.Lock()
+	defer statuses.Unlock()
+	statuses.byErr[err] = status
+}
+
+// Status returns the HTTP status code with which err should be reported to a
+// client. It is that of the first error in err's tree of causes, in the order
+// in which errors.Walk visits them, which determines one, either by being
+// registered with RegisterStatus, by implementing
+//
+//	type httpStatuser interface {
+//	        HTTPStatus() int
+//	}
+//
+// or by having a code, as errors.Code reports it.
+//
+// Status returns http.StatusOK if err is nil, and
+// http.StatusInternalServerError if no error in the tree determines a
+// status.
+func Status(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	status := http.StatusInternalServerError
+	errors.Walk(err, func(err error) bool {
+		s, ok := statusOf(err)
+		if ok {
+			status = s
+		}
+		return !ok
+	})
+	return status
+}
+
+// statusOf returns the HTTP status err itself determines, if any.
+func statusOf(err error) (int, bool) {
+	type httpStatuser interface {
+		HTTPStatus() int
+	}
+	type coder interface {
+		ErrorCode() errors.ErrorCode
+	}
+
+	if st, ok := err.(httpStatuser); ok {
+		return st.HTTPStatus(), true
+	}
+	if status, ok := registeredStatus(err); ok {
+		return status, true
+	}
+	// errors.Code reports the code of the outermost error in a chain which
+	// has one; asking it only of errors which may have one themselves keeps
+	// it from reaching past the errors Walk has yet to visit.
+	switch err.(type) {
+	case errors.ErrorCode, coder:
+		if code := errors.Code(err); code != errors.CodeUnknown {
+			return CodeStatus(code), true
+		}
+	}
+	return 0, false
+}
+
+// registeredStatus returns the status registered for err, if any. Looking err
+// up panics if it is of a comparable type but holds values which are not,
+// such as a struct with an interface field holding a slice; no such error can
+// have been registered, so registeredStatus reports none.
+func registeredStatus(err error) (status int, ok bool) {
+	if !reflect.TypeOf(err).Comparable() {
+		return 0, false
+	}
+	defer func() {
+		if recover() != nil {
+			status, ok = 0, false
+		}
+	}()
+	statuses.RLock()
+	defer statuses.RUnlock()
+	status, ok = statuses.byErr[err]
+	return status, ok
+}
+
+// CodeStatus returns the HTTP status code which corresponds to code.
+func CodeStatus(code errors.ErrorCode) int {
+	switch code {
+	case errors.CodeOK:
+		return http.StatusOK
+	case errors.CodeCanceled:
+		return 499 // Client Closed Request
+	case errors.CodeInvalidArgument, errors.CodeFailedPrecondition, errors.CodeOutOfRange:
+		return http.StatusBadRequest
+	case errors.CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case errors.CodeNotFound:
+		return http.StatusNotFound
+	case errors.CodeAlreadyExists, errors.CodeAborted:
+		return http.StatusConflict
+	case errors.CodePermissionDenied:
+		return http.StatusForbidden
+	case errors.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case errors.CodeUnimplemented:
+		return http.StatusNotImplemented
+	case errors.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case errors.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
+// problem is an RFC 9457 problem details object.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code,omitempty"`
+}
+
+// WriteProblem reports err to the client of r as an RFC 9457
+// application/problem+json response with the status returned by Status, and
+// logs it, with its stack traces, in the %+v format.
+//
+// The detail of the response is the public message of err, as
+// errors.LookupPublicMessage returns it, if an error in its chain has one,
+// and is left out otherwise: the messages of errors are logged but not sent,
+// as they may describe the server's internals or hold sensitive data. The
+// code of the response is the errors.PublicCode of err, or else its
+// errors.Code.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	status := Status(err)
+	logError(r, status, err)
+
+	p := problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+	if p.Title == "" {
+		p.Title = fmt.Sprintf("Status %d", status)
+	}
+	if msg, ok := errors.LookupPublicMessage(err); ok {
+		p.Detail = msg
+	}
+	if p.Code = errors.PublicCode(err); p.Code == "" {
+		if code := errors.Code(err); code != errors.CodeOK && code != errors.CodeUnknown {
+			p.Code = code.String()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(p)
+}
+
+var errorLog struct {
+	sync.Mutex
+	logger *log.Logger
+}
+
+// SetErrorLog sets the logger to which WriteProblem logs errors and returns
+// the previous one. A nil logger, the default, logs to the standard logger
+// of package log.
+func SetErrorLog(l *log.Logger) *log.Logger {
+	errorLog.Lock()
+	defer errorLog.Unlock()
+	prev := errorLog.logger
+	errorLog.logger = l
+	return prev
+}
+
+// logError logs err, reported to the client of r with status.
+func logError(r *http.Request, status int, err error) {
+	errorLog.Lock()
+	l := errorLog.logger
+	errorLog.Unlock()
+
+	const format = "%s %s: %d: %+v"
+	if l == nil {
+		log.Printf(format, r.Method, r.URL.Path, status, err)
+		return
+	}
+	l.Printf(format, r.Method, r.URL.Path, status, err)
+}
+
+// HandlerFunc is an HTTP handler which returns an error rather than writing
+// one. Its ServeHTTP method reports the errors the handler returns, and the
+// panics it raises, with WriteProblem.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP calls f(w, r) and reports any error it returns or panic it
+// raises with WriteProblem.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer recoverHTTP(w, r)
+	if err := f(w, r); err != nil {
+		WriteProblem(w, r, err)
+	}
+}
+
+// Recoverer returns an http.Handler which calls next and reports any panic
+// it raises with WriteProblem. Panics with http.ErrAbortHandler, which abort
+// the response on purpose, are passed on.
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer recoverHTTP(w, r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoverHTTP reports a panic in the handler serving r with WriteProblem.
+// It must be deferred itself, for recover to stop the panic.
+func recoverHTTP(w http.ResponseWriter, r *http.Request) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if p == http.ErrAbortHandler {
+		panic(p)
+	}
+	WriteProblem(w, r, errors.FromPanic(p))
+}
@@ -0,0 +1,186 @@
+package httperrors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	errHTTPGone     = fmt.Errorf("gone")
+	errUserNotFound = errors.Define("user %s not found", errors.CodeNotFound)
+)
+
+func init() {
+	RegisterStatus(errHTTPGone, http.StatusGone)
+}
+
+type teapotError struct{}
+
+func (teapotError) Error() string   { return "teapot" }
+func (teapotError) HTTPStatus() int { return http.StatusTeapot }
+
+// interfaceError is an error of a comparable type whose values may not be.
+type interfaceError struct{ v interface{} }
+
+func (interfaceError) Error() string { return "interface" }
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusOK},
+		{io.EOF, http.StatusInternalServerError},
+		{errors.New("error"), http.StatusInternalServerError},
+		{errors.WithCode(io.EOF, errors.CodeNotFound), http.StatusNotFound},
+		{errors.Wrap(errors.WithCode(io.EOF, errors.CodeInvalidArgument), "wrapped"), http.StatusBadRequest},
+		{errors.WithCode(errors.Wrap(errHTTPGone, "wrapped"), errors.CodeUnavailable), http.StatusServiceUnavailable},
+		{errors.Wrap(errHTTPGone, "wrapped"), http.StatusGone},
+		{errors.WithMessage(teapotError{}, "brewing"), http.StatusTeapot},
+		{errors.WithMessage(errors.CodeUnauthenticated, "no token"), http.StatusUnauthorized},
+		{errors.Wrap(errUserNotFound.New("bob"), "lookup"), http.StatusNotFound},
+		{errors.Wrap(interfaceError{[]int{1}}, "wrapped"), http.StatusInternalServerError},
+		{errors.Join(errors.WithCode(io.EOF, errors.CodeNotFound)), http.StatusNotFound},
+		{errors.Join(io.EOF, errors.Wrap(errHTTPGone, "wrapped")), http.StatusGone},
+		{errors.WithCode(errors.Join(teapotError{}), errors.CodeUnavailable), http.StatusServiceUnavailable},
+	}
+
+	for i, tt := range tests {
+		if got := Status(tt.err); got != tt.want {
+			t.Errorf("test %d: Status(%v): got %d, want %d", i+1, tt.err, got, tt.want)
+		}
+	}
+}
+
+// serveProblem serves a request with h and decodes the problem it reports,
+// returning it with the status of the response and the log written.
+func serveProblem(t *testing.T, h http.Handler) (*httptest.ResponseRecorder, problem, string) {
+	t.Helper()
+	var logged bytes.Buffer
+	defer SetErrorLog(SetErrorLog(log.New(&logged, "", 0)))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+
+	if got, want := rec.Header().Get("Content-Type"), "application/problem+json"; got != want {
+		t.Errorf("Content-Type: got %q, want %q", got, want)
+	}
+	var p problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body.Bytes(), err)
+	}
+	if p.Status != rec.Code {
+		t.Errorf("status: got %d, want %d", p.Status, rec.Code)
+	}
+	return rec, p, logged.String()
+}
+
+func TestHandlerFuncError(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.WithCode(errors.New("user 42 not found"), errors.CodeNotFound)
+	})
+	rec, p, logged := serveProblem(t, h)
+
+	want := problem{Type: "about:blank", Title: "Not Found", Status: 404, Code: "NotFound"}
+	if rec.Code != http.StatusNotFound || p != want {
+		t.Errorf("got %d %+v, want %d %+v", rec.Code, p, http.StatusNotFound, want)
+	}
+	if !strings.HasPrefix(logged, "GET /users/42: 404: user 42 not found\ngithub.com/pkg/errors/httperrors.TestHandlerFuncError") {
+		t.Errorf("log: got %q, want the error with its stack", logged)
+	}
+}
+
+func TestHandlerFuncInternalError(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.Wrap(io.ErrUnexpectedEOF, "query users table")
+	})
+	rec, p, logged := serveProblem(t, h)
+
+	if rec.Code != http.StatusInternalServerError || p.Detail != "" {
+		t.Errorf("got %d %+v, want 500 without detail", rec.Code, p)
+	}
+	if !strings.Contains(logged, "query users table") {
+		t.Errorf("log: got %q, want the internal message", logged)
+	}
+}
+
+func TestHandlerFuncPanic(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	})
+	rec, _, logged := serveProblem(t, h)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status: got %d, want 500", rec.Code)
+	}
+	if !strings.Contains(logged, "panic: boom\n") {
+		t.Errorf("log: got %q, want the panic", logged)
+	}
+}
+
+func TestRecoverer(t *testing.T) {
+	h := Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.WithCode(io.EOF, errors.CodeUnavailable))
+	}))
+	rec, p, _ := serveProblem(t, h)
+
+	if rec.Code != http.StatusServiceUnavailable || p.Code != "Unavailable" {
+		t.Errorf("got %d %+v, want 503 Unavailable", rec.Code, p)
+	}
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recover(): got %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestHandlerFuncPublicMessage(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		err := errors.WithPublicCode(io.ErrUnexpectedEOF, "storage_unavailable", "Try again later.")
+		return errors.Wrap(err, "query users table")
+	})
+	rec, p, logged := serveProblem(t, h)
+
+	if rec.Code != http.StatusInternalServerError || p.Detail != "Try again later." || p.Code != "storage_unavailable" {
+		t.Errorf("got %d %+v, want 500 with the public message and code", rec.Code, p)
+	}
+	if !strings.Contains(logged, ": 500: unexpected EOF\n") || !strings.Contains(logged, "\nquery users table\n") {
+		t.Errorf("log: got %q, want the internal message", logged)
+	}
+}
+
+func TestHandlerFuncClientPublicMessage(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		err := errors.WithPublicMessage(errors.WithCode(io.EOF, errors.CodeNotFound), "No such user.")
+		return errors.Wrapf(err, "select * from users where email=%q", "dave@example.com")
+	})
+	rec, p, _ := serveProblem(t, h)
+
+	if rec.Code != http.StatusNotFound || p.Detail != "No such user." {
+		t.Errorf("got %d %+v, want 404 with the public message", rec.Code, p)
+	}
+}
+
+func TestHandlerFuncIncomparableError(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.Wrap(interfaceError{[]int{1}}, "wrapped")
+	})
+	rec, _, _ := serveProblem(t, h)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status: got %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,166 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Join returns an error that wraps the given errors and records the stack
+// trace at the point Join was called. Any nil error values are discarded.
+// If every value in errs is nil, Join returns nil.
+//
+// The error's message is the messages of errs separated by newlines. Under
+// %+v each error is printed in the extended format, indented beneath the
+// others, followed by the stack trace of the Join.
+//
+// The wrapped errors are returned by an Unwrap() []error method, through
+// which Is and As (on Go 1.20 or later) search every one of them, and by
+// Causes.
+func Join(errs ...error) error {
+	errs = appendErrors(nil, errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &multiError{
+		errs:  errs,
+		stack: callers(),
+	}
+}
+
+// Append returns an error that wraps err together with errs, discarding any
+// nil values. If err was returned by Join or Append, errs are added to the
+// errors it wraps and the stack trace it recorded is kept; err itself is not
+// modified. Otherwise Append behaves as Join(err, errs...).
+func Append(err error, errs ...error) error {
+	if m, ok := err.(*multiError); ok {
+		return &multiError{
+			errs:  appendErrors(appendErrors(nil, m.errs), errs),
+			stack: m.stack,
+		}
+	}
+	all := appendErrors(appendErrors(nil, []error{err}), errs)
+	if len(all) == 0 {
+		return nil
+	}
+	return &multiError{
+		errs:  all,
+		stack: callers(),
+	}
+}
+
+// appendErrors appends the non-nil errors in errs to dst.
+func appendErrors(dst, errs []error) []error {
+	for _, err := range errs {
+		if err != nil {
+			dst = append(dst, err)
+		}
+	}
+	return dst
+}
+
+// multiError is an error that wraps several errors, and has a stack.
+type multiError struct {
+	errs []error
+	*stack
+}
+
+func (m *multiError) Error() string { return joinMessages(m.errs) }
+
+func (m *multiError) layerMessage() (string, bool) { return "", false }
+
+func (m *multiError) unredacted() string {
+	msgs := make([]string, len(m.errs))
+	for i, err := range m.errs {
+		msgs[i] = Unredacted(err)
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (m *multiError) toJSONLayer(l *jsonLayer) {
+	l.Type = "join"
+	l.Errors = toJSONErrors(m.errs)
+}
+
+// Unwrap provides compatibility for Go 1.20 error trees.
+func (m *multiError) Unwrap() []error { return m.errs }
+
+func (m *multiError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			formatErrors(s, m.errs)
+			m.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, m.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", m.Error())
+	}
+}
+
+// joinMessages returns the messages of errs separated by newlines.
+func joinMessages(errs []error) string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = errorText(err)
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// formatErrors writes errs in the extended format, each indented beneath a
+// line counting them.
+func formatErrors(s fmt.State, errs []error) {
+	formatList(s, strconv.Itoa(len(errs))+" errors occurred:", errs)
+}
+
+// formatList writes errs in the extended format, each indented beneath the
+// header line.
+func formatList(s fmt.State, header string, errs []error) {
+	io.WriteString(s, header)
+	for _, err := range errs {
+		lines := strings.Split(sprintCause(s, err), "\n")
+		io.WriteString(s, "\n\t* ")
+		io.WriteString(s, lines[0])
+		for _, line := range lines[1:] {
+			io.WriteString(s, "\n\t  ")
+			io.WriteString(s, line)
+		}
+	}
+}
+
+// Causes returns the underlying causes of err. Where err, or one of its
+// causes, wraps several errors, as those returned by Join do, Causes returns
+// the causes of each of them in turn; otherwise it returns the single cause
+// Cause would. If err is nil, Causes returns nil.
+func Causes(err error) []error {
+	if err == nil {
+		return nil
+	}
+	return appendCauses(nil, err)
+}
+
+func appendCauses(dst []error, err error) []error {
+	type multiWrapper interface {
+		Unwrap() []error
+	}
+
+	for {
+		if m, ok := err.(multiWrapper); ok {
+			for _, err := range m.Unwrap() {
+				if err != nil {
+					dst = appendCauses(dst, err)
+				}
+			}
+			return dst
+		}
+		next := unwrapOnce(err)
+		if next == nil {
+			return append(dst, err)
+		}
+		err = next
+	}
+}
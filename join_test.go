@@ -0,0 +1,111 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestJoinNil(t *testing.T) {
+	if got := Join(); got != nil {
+		t.Errorf("Join(): got %#v, expected nil", got)
+	}
+	if got := Join(nil, nil); got != nil {
+		t.Errorf("Join(nil, nil): got %#v, expected nil", got)
+	}
+	if got := Append(nil, nil); got != nil {
+		t.Errorf("Append(nil, nil): got %#v, expected nil", got)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	err1 := New("err1")
+	tests := []struct {
+		errs []error
+		want []error
+		msg  string
+	}{
+		{[]error{io.EOF}, []error{io.EOF}, "EOF"},
+		{[]error{err1, nil, io.EOF}, []error{err1, io.EOF}, "err1\nEOF"},
+	}
+
+	for i, tt := range tests {
+		err := Join(tt.errs...)
+		if got := err.Error(); got != tt.msg {
+			t.Errorf("test %d: Join(): got %q, want %q", i+1, got, tt.msg)
+		}
+		got := err.(interface{ Unwrap() []error }).Unwrap()
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("test %d: Join().Unwrap(): got %v, want %v", i+1, got, tt.want)
+		}
+		testFormatRegexp(t, i, err.(stackTracer).StackTrace()[0], "%n", "TestJoin")
+	}
+}
+
+func TestAppend(t *testing.T) {
+	err1, err2 := New("err1"), New("err2")
+	joined := Join(err1)
+
+	err := Append(joined, nil, err2)
+	want := []error{err1, err2}
+	if got := err.(interface{ Unwrap() []error }).Unwrap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Append(Join(err1), err2): got %v, want %v", got, want)
+	}
+	if got := joined.(interface{ Unwrap() []error }).Unwrap(); len(got) != 1 {
+		t.Errorf("Append(Join(err1), err2) modified Join(err1): %v", got)
+	}
+	if got, want := stackOf(err), stackOf(joined); !reflect.DeepEqual(got, want) {
+		t.Errorf("Append(Join(err1), err2) did not keep the stack of Join:\n got %v\nwant %v", got, want)
+	}
+
+	err = Append(io.EOF, err1)
+	want = []error{io.EOF, err1}
+	if got := err.(interface{ Unwrap() []error }).Unwrap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Append(io.EOF, err1): got %v, want %v", got, want)
+	}
+	testFormatRegexp(t, 0, err.(stackTracer).StackTrace()[0], "%n", "TestAppend")
+}
+
+func TestFormatJoin(t *testing.T) {
+	err := Join(New("err1"), io.EOF)
+
+	testFormatRegexp(t, 0, err, "%s", "err1\nEOF")
+	testFormatRegexp(t, 1, err, "%v", "err1\nEOF")
+	testFormatRegexp(t, 2, err, "%q", `"err1\\nEOF"`)
+
+	want := "^2 errors occurred:\n" +
+		"\t\\* err1\n" +
+		"\t  github.com/pkg/errors.TestFormatJoin\n" +
+		"\t  \t.+/github.com/pkg/errors/join_test.go:72\n" +
+		"(\t  .*\n)*" +
+		"\t\\* EOF\n" +
+		"github.com/pkg/errors.TestFormatJoin\n" +
+		"\t.+/github.com/pkg/errors/join_test.go:72\n"
+	for _, format := range []string{"%+v", "%+#v"} {
+		if got := fmt.Sprintf(format, err); !regexp.MustCompile(want).MatchString(got) {
+			t.Errorf("fmt.Sprintf(%q, err):\n got: %q\nwant: %q", format, got, want)
+		}
+	}
+}
+
+func TestCauses(t *testing.T) {
+	err1 := New("err1")
+	tests := []struct {
+		err  error
+		want []error
+	}{
+		{nil, nil},
+		{io.EOF, []error{io.EOF}},
+		{Wrap(io.EOF, "wrapped"), []error{io.EOF}},
+		{Join(Wrap(io.EOF, "wrapped"), err1), []error{io.EOF, err1}},
+		{Wrap(Join(io.EOF, Join(WithStack(err1), io.ErrUnexpectedEOF)), "wrapped"), []error{io.EOF, err1, io.ErrUnexpectedEOF}},
+	}
+
+	for i, tt := range tests {
+		if got := Causes(tt.err); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("test %d: Causes(%v): got %v, want %v", i+1, tt.err, got, tt.want)
+		}
+	}
+}
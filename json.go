@@ -0,0 +1,177 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonError is the JSON form of an error, as produced by the MarshalJSON
+// methods of the errors returned by this package:
+//
+//	{
+//	  "message": "read config: open /etc/app.yaml: permission denied",
+//	  "layers": [
+//	    {"type": "stack", "stack": [{"function": "main.load", "file": "/src/main.go", "line": 12, ...}]},
+//	    {"type": "message", "message": "read config"},
+//	    {"type": "fields", "fields": {"path": "/etc/app.yaml"}},
+//	    {"type": "*fs.PathError", "message": "open /etc/app.yaml: permission denied"}
+//	  ],
+//	  "cause": {"type": "*fs.PathError", "message": "open /etc/app.yaml: permission denied"}
+//	}
+//
+// Layers lists the error's chain of causes, outermost first. Layers of the
+// errors from this package have the types "fundamental", "sentinel",
+// "defined", "stack", "message", "fields", "code", "spawn", "retry",
+// "attempts", "public" and "join"; other errors are typed by their Go type.
+// Layers which are errors registered with Register record the name they were
+// registered under as "sentinel".
+type jsonError struct {
+	Message string      `json:"message"`
+	Layers  []jsonLayer `json:"layers"`
+	Cause   jsonCause   `json:"cause"`
+}
+
+// jsonLayer is the JSON form of one error in a chain of causes.
+type jsonLayer struct {
+	Type       string      `json:"type"`
+	Message    string      `json:"message,omitempty"`
+	Fields     Fields      `json:"fields,omitempty"`
+	Stack      []FrameInfo `json:"stack,omitempty"`
+	MoreFrames int         `json:"more_frames,omitempty"`
+	Errors     []jsonError `json:"errors,omitempty"`
+	Sentinel   string      `json:"sentinel,omitempty"`
+	Code       string      `json:"code,omitempty"`
+	Retryable  *bool       `json:"retryable,omitempty"`
+	RetryAfter string      `json:"retry_after,omitempty"`
+
+	PublicMessage string `json:"public_message,omitempty"`
+	PublicCode    string `json:"public_code,omitempty"`
+}
+
+// jsonCause is the JSON form of the root cause of a chain.
+type jsonCause struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f *fundamental) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(f)) }
+
+// MarshalJSON implements json.Marshaler.
+func (e *sentinelError) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(e)) }
+
+// MarshalJSON implements json.Marshaler.
+func (e *definedError) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(e)) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *withStack) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(w)) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *withMessage) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(w)) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *withFields) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(w)) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *withCode) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(w)) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *withSpawn) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(w)) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *withRetry) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(w)) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *withAttempts) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(w)) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *withPublic) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(w)) }
+
+// MarshalJSON implements json.Marshaler.
+func (m *multiError) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(m)) }
+
+// toJSON returns the JSON form of err.
+func toJSON(err error) jsonError {
+	doc := jsonError{Message: errorText(err)}
+	for {
+		layer := toJSONLayer(err)
+		doc.Layers = append(doc.Layers, layer)
+		next := unwrapOnce(err)
+		if next == nil {
+			doc.Cause = jsonCause{
+				Type:    layer.Type,
+				Message: errorText(err),
+			}
+			return doc
+		}
+		err = next
+	}
+}
+
+// jsonLayerer is implemented by the errors of this package, to set the type
+// of their layer in its JSON form and the members particular to them.
+type jsonLayerer interface {
+	toJSONLayer(l *jsonLayer)
+}
+
+// toJSONLayer returns the JSON form of err, without its causes. Errors from
+// other packages are typed by their Go type.
+func toJSONLayer(err error) jsonLayer {
+	layer := jsonLayer{
+		Type:     fmt.Sprintf("%T", err),
+		Sentinel: sentinelName(err),
+		Fields:   jsonFields(fieldsOf(err)),
+	}
+	if msg, ok := layerMessage(err); ok {
+		layer.Message = msg
+	}
+	if f, ok := err.(framer); ok {
+		layer.Stack, layer.MoreFrames = f.frameInfos()
+	}
+	if code, ok := codeOf(err); ok {
+		layer.Code = code.String()
+	}
+	if retryable, after, ok := retryOf(err); ok {
+		layer.Retryable = &retryable
+		if after > 0 {
+			layer.RetryAfter = after.String()
+		}
+	}
+	if msg, code, ok := publicOf(err); ok {
+		layer.PublicMessage, layer.PublicCode = msg, code
+	}
+	if l, ok := err.(jsonLayerer); ok {
+		l.toJSONLayer(&layer)
+	}
+	return layer
+}
+
+// jsonFields returns fields as they are marshalled to JSON, with their values
+// scrubbed by the current Scrubbers. Each value is marshalled on its own, so
+// that one which cannot be, such as a channel, is marshalled as its %v form
+// rather than failing the whole error.
+func jsonFields(fields Fields) Fields {
+	if len(fields) == 0 {
+		return nil
+	}
+	f := make(Fields, len(fields))
+	for k, v := range fields {
+		v = scrubValue(v)
+		b, err := json.Marshal(v)
+		if err != nil {
+			f[k] = fmt.Sprint(v)
+			continue
+		}
+		f[k] = json.RawMessage(b)
+	}
+	return f
+}
+
+// toJSONErrors returns the JSON form of each of errs.
+func toJSONErrors(errs []error) []jsonError {
+	var docs []jsonError
+	for _, err := range errs {
+		docs = append(docs, toJSON(err))
+	}
+	return docs
+}
@@ -2,6 +2,8 @@ package errors
 
 import (
 	"encoding/json"
+	"io"
+	"reflect"
 	"regexp"
 	"testing"
 )
@@ -49,3 +51,107 @@ func TestFrameMarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestMarshalJSON(t *testing.T) {
+	err := Wrap(WithFields(New("error"), Fields{"a": 1}), "wrapped")
+
+	b, e := json.Marshal(err)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var got jsonError
+	if e := json.Unmarshal(b, &got); e != nil {
+		t.Fatal(e)
+	}
+
+	if got.Message != "wrapped: error" {
+		t.Errorf("message: got %q, want %q", got.Message, "wrapped: error")
+	}
+	if want := (jsonCause{Type: "fundamental", Message: "error"}); got.Cause != want {
+		t.Errorf("cause: got %+v, want %+v", got.Cause, want)
+	}
+	var types []string
+	for _, l := range got.Layers {
+		types = append(types, l.Type)
+	}
+	if want := []string{"stack", "message", "fields", "fundamental"}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("layers: got %v, want %v", types, want)
+	}
+	if got.Layers[1].Message != "wrapped" || got.Layers[3].Message != "error" {
+		t.Errorf("layers: got messages %q and %q, want %q and %q", got.Layers[1].Message, got.Layers[3].Message, "wrapped", "error")
+	}
+	if want := (Fields{"a": float64(1)}); !reflect.DeepEqual(got.Layers[2].Fields, want) {
+		t.Errorf("layers[2].fields: got %v, want %v", got.Layers[2].Fields, want)
+	}
+	for _, i := range []int{0, 3} {
+		st := got.Layers[i].Stack
+		if len(st) == 0 || st[0].Function != "github.com/pkg/errors.TestMarshalJSON" || st[0].Line != 56 {
+			t.Errorf("layers[%d].stack: got %+v, want the frames of TestMarshalJSON", i, st)
+		}
+	}
+}
+
+func TestMarshalJSONSchema(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{{
+		WithMessage(io.EOF, "read"),
+		`{"message":"read: EOF","layers":[{"type":"message","message":"read"},{"type":"*errors.errorString","message":"EOF"}],"cause":{"type":"*errors.errorString","message":"EOF"}}`,
+	}, {
+		WithStackMode(io.EOF, StackNone),
+		`{"message":"EOF","layers":[{"type":"stack"},{"type":"*errors.errorString","message":"EOF"}],"cause":{"type":"*errors.errorString","message":"EOF"}}`,
+	}}
+
+	for i, tt := range tests {
+		got, err := json.Marshal(tt.err)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("test %d: json.Marshal:\n got %s\nwant %s", i+1, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalJSONJoin(t *testing.T) {
+	b, err := json.Marshal(Join(io.EOF, WithMessage(io.ErrUnexpectedEOF, "read")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got jsonError
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Layers) != 1 || got.Layers[0].Type != "join" || len(got.Layers[0].Stack) == 0 {
+		t.Fatalf("layers: got %+v, want a join with a stack", got.Layers)
+	}
+	errs := got.Layers[0].Errors
+	if len(errs) != 2 || errs[0].Message != "EOF" || errs[1].Layers[0].Message != "read" {
+		t.Errorf("layers[0].errors: got %+v, want EOF and read: unexpected EOF", errs)
+	}
+	if want := (jsonCause{Type: "join", Message: "EOF\nread: unexpected EOF"}); got.Cause != want {
+		t.Errorf("cause: got %+v, want %+v", got.Cause, want)
+	}
+}
+
+func TestMarshalJSONUnsupportedFields(t *testing.T) {
+	err := WithFields(io.EOF, Fields{"n": 1, "c": 1 + 2i, "ch": make(chan int)})
+
+	b, e := json.Marshal(err)
+	if e != nil {
+		t.Fatalf("json.Marshal: %v", e)
+	}
+	var got jsonError
+	if e := json.Unmarshal(b, &got); e != nil {
+		t.Fatal(e)
+	}
+	fields := got.Layers[0].Fields
+	if fields["n"] != float64(1) || fields["c"] != "(1+2i)" {
+		t.Errorf("fields: got %v, want n=1 and c=(1+2i)", fields)
+	}
+	if ch, _ := fields["ch"].(string); !regexp.MustCompile("^0x[0-9a-f]+$").MatchString(ch) {
+		t.Errorf("fields[ch]: got %#v, want the address of the channel", fields["ch"])
+	}
+}
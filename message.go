@@ -0,0 +1,77 @@
+package errors
+
+import (
+	"fmt"
+	"sync"
+)
+
+// errorMessage is the message of a fundamental, withMessage or definedError
+// error. Messages given as a format and arguments, by Errorf, Wrapf,
+// WithMessagef and the like, are formatted when first used rather than when
+// the error is created, as many errors are only compared with Is and
+// discarded, never printed.
+//
+// An errorMessage must not be copied after first use.
+type errorMessage struct {
+	once sync.Once
+	msg  string
+
+	// lazy is set if msg is still to be formatted from format and args.
+	lazy   bool
+	format string
+	args   []interface{}
+
+	// secret is set once the message is formatted if any of args is
+	// Sensitive. The args of other messages are released then.
+	secret bool
+}
+
+// formatMessage returns the message formatted from format and args, as
+// fmt.Sprintf formats it, on first use. The args slice is copied, so that
+// the message does not change, and does not race with its formatting, when
+// the caller reuses it; the values it holds are formatted as they are when
+// the message is first used.
+func formatMessage(format string, args []interface{}) errorMessage {
+	return errorMessage{
+		lazy:   true,
+		format: format,
+		args:   append([]interface{}(nil), args...),
+	}
+}
+
+// text returns the message, formatting it on first use.
+func (m *errorMessage) text() string {
+	m.once.Do(m.render)
+	return m.msg
+}
+
+func (m *errorMessage) render() {
+	if !m.lazy {
+		return
+	}
+	m.msg = fmt.Sprintf(m.format, m.args...)
+	for _, arg := range m.args {
+		if _, ok := arg.(Sensitive); ok {
+			m.secret = true
+			return
+		}
+	}
+	m.args = nil
+}
+
+// revealed returns the message formatted with the values of its Sensitive
+// arguments revealed.
+func (m *errorMessage) revealed() string {
+	msg := m.text()
+	if !m.secret {
+		return msg
+	}
+	args := make([]interface{}, len(m.args))
+	for i, arg := range m.args {
+		if s, ok := arg.(Sensitive); ok {
+			arg = s.v
+		}
+		args[i] = arg
+	}
+	return fmt.Sprintf(m.format, args...)
+}
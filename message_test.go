@@ -0,0 +1,58 @@
+package errors
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestFormatMessage(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("before")
+	args := []interface{}{"read", &buf}
+	err := Wrapf(io.EOF, "%s %s", args...)
+	args[0] = "write"
+	buf.Reset()
+	buf.WriteString("after")
+
+	// The args are copied when the error is created, but the message is
+	// formatted, with the values they point to, when it is first used.
+	if got, want := err.Error(), "read after: EOF"; got != want {
+		t.Errorf("Error(): got %q, want %q", got, want)
+	}
+	buf.Reset()
+	if got, want := err.Error(), "read after: EOF"; got != want {
+		t.Errorf("Error() again: got %q, want %q", got, want)
+	}
+}
+
+func TestFormatMessageConcurrent(t *testing.T) {
+	err := Errorf("attempt %d", 3)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, want := err.Error(), "attempt 3"; got != want {
+				t.Errorf("Error(): got %q, want %q", got, want)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFormatMessageKeepsSecretArgs(t *testing.T) {
+	f := Errorf("user %d", 42).(*fundamental)
+	_ = f.Error()
+	if f.msg.args != nil {
+		t.Errorf("args: got %v, want them dropped", f.msg.args)
+	}
+
+	args := []interface{}{Secret(42)}
+	f = Errorf("user %v", args...).(*fundamental)
+	args[0] = Secret(43)
+	if got := Unredacted(f); got != "user 42" {
+		t.Errorf("Unredacted(): got %q, want %q", got, "user 42")
+	}
+}
@@ -0,0 +1,101 @@
+package errors
+
+import (
+	"runtime"
+	"strings"
+)
+
+// FromPanic returns an error for p, a value recovered from a panic, which
+// records the stack trace of the panic site: the frames of the deferred
+// function which recovered it and of the runtime's panic handling are left
+// out. It must be called by the deferred function which recovered p, while
+// the panicking goroutine's stack is still intact.
+//
+// If p is an error, the returned error wraps it as Wrap(p, "panic") does, so
+// that its Cause is p; otherwise it is an error with the message
+// "panic: " followed by p formatted with %v, which formats as New's errors
+// do. If p is nil, FromPanic returns nil.
+func FromPanic(p interface{}) error {
+	if p == nil {
+		return nil
+	}
+	if err, ok := p.(error); ok {
+		return &withStack{
+			&withMessage{
+				cause: err,
+				msg:   errorMessage{msg: "panic"},
+			},
+			panicStack(),
+		}
+	}
+	return &fundamental{
+		msg:   formatMessage("panic: %v", []interface{}{p}),
+		stack: panicStack(),
+	}
+}
+
+// Recover recovers a panic in the calling goroutine and stores the error
+// FromPanic returns for it in the value pointed to by err. It must be
+// deferred itself, typically to set a named result:
+//
+//	func parse(s string) (err error) {
+//	        defer errors.Recover(&err)
+//	        ...
+//	}
+//
+// If the goroutine is not panicking, err is left unchanged.
+func Recover(err *error) {
+	if p := recover(); p != nil {
+		*err = FromPanic(p)
+	}
+}
+
+// panicStack records the stack of the panic site of the panicking goroutine,
+// as mode and depth require, for FromPanic.
+func panicStack() *stack {
+	mode := CurrentStackMode()
+	switch {
+	case mode == StackNone:
+		return &stack{}
+	case !sample(4):
+		return &stack{sampledOut: true}
+	}
+
+	pcs := trimPanic(callersDepth(4, 0).pcs)
+	if mode == StackCaller && len(pcs) > 1 {
+		pcs = pcs[:1]
+	}
+	more := 0
+	if depth := StackDepth(); depth > 0 && len(pcs) > depth {
+		pcs, more = pcs[:depth], len(pcs)-depth
+	}
+	return &stack{pcs: pcs, more: more}
+}
+
+// trimPanic returns the frames of pcs below runtime.gopanic and the runtime
+// functions which called it, such as runtime.sigpanic for a nil pointer
+// dereference: the frames of the panic site and its callers. If pcs has no
+// runtime.gopanic frame, it is returned unchanged.
+func trimPanic(pcs []uintptr) []uintptr {
+	for i, pc := range pcs {
+		if pcFuncName(pc) != "runtime.gopanic" {
+			continue
+		}
+		i++
+		for i < len(pcs) && strings.HasPrefix(pcFuncName(pcs[i]), "runtime.") {
+			i++
+		}
+		return pcs[i:]
+	}
+	return pcs
+}
+
+// pcFuncName returns the name of the function containing the return address
+// pc, as recorded by runtime.Callers.
+func pcFuncName(pc uintptr) string {
+	fn := runtime.FuncForPC(pc - 1)
+	if fn == nil {
+		return ""
+	}
+	return fn.Name()
+}
@@ -0,0 +1,72 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+func panicking(v interface{}) (err error) {
+	defer Recover(&err)
+	panic(v)
+}
+
+func dereferencing() (err error) {
+	defer Recover(&err)
+	var p *int
+	return fmt.Errorf("%d", *p)
+}
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		err    error
+		msg    string
+		format string
+	}{{
+		panicking("boom"),
+		"panic: boom",
+		"panic: boom\n" +
+			"github.com/pkg/errors.panicking\n" +
+			"\t.+/github.com/pkg/errors/panic_test.go:11\n" +
+			"github.com/pkg/errors.TestRecover\n" +
+			"\t.+/github.com/pkg/errors/panic_test.go:26\n",
+	}, {
+		panicking(io.EOF),
+		"panic: EOF",
+		"EOF\n" +
+			"panic\n" +
+			"github.com/pkg/errors.panicking\n" +
+			"\t.+/github.com/pkg/errors/panic_test.go:11\n",
+	}, {
+		dereferencing(),
+		"panic: runtime error: invalid memory address or nil pointer dereference",
+		"runtime error: invalid memory address or nil pointer dereference\n" +
+			"panic\n" +
+			"github.com/pkg/errors.dereferencing\n" +
+			"\t.+/github.com/pkg/errors/panic_test.go:17\n",
+	}}
+
+	for i, tt := range tests {
+		if got := tt.err.Error(); got != tt.msg {
+			t.Errorf("test %d: Error(): got %q, want %q", i+1, got, tt.msg)
+		}
+		testFormatRegexp(t, i, tt.err, "%+v", tt.format)
+	}
+
+	if Cause(panicking(io.EOF)) != io.EOF {
+		t.Errorf("Cause(panicking(io.EOF)): want io.EOF")
+	}
+}
+
+func TestRecoverNoPanic(t *testing.T) {
+	err := io.EOF
+	func() {
+		defer Recover(&err)
+	}()
+	if err != io.EOF {
+		t.Errorf("Recover(): got %v, want err unchanged", err)
+	}
+	if got := FromPanic(nil); got != nil {
+		t.Errorf("FromPanic(nil): got %#v, want nil", got)
+	}
+}
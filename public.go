@@ -0,0 +1,138 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+// DefaultPublicMessage is the message PublicMessage returns for errors which
+// have no public message.
+const DefaultPublicMessage = "internal error"
+
+// WithPublicMessage annotates err with msg, a message which may be shown to
+// the users of a program, unlike err's own message which may reveal its
+// internals. The public message is not part of the error's message; it is
+// reported by PublicMessage and printed under %+v.
+// If err is nil, WithPublicMessage returns nil.
+func WithPublicMessage(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return &withPublic{
+		cause: err,
+		msg:   msg,
+	}
+}
+
+// WithPublicCode annotates err with msg, as WithPublicMessage does, and with
+// code, an identifier of the error such as "user_not_found" which may be
+// shown to the users of a program, as reported by PublicCode.
+// If err is nil, WithPublicCode returns nil.
+func WithPublicCode(err error, code, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return &withPublic{
+		cause: err,
+		msg:   msg,
+		code:  code,
+	}
+}
+
+type withPublic struct {
+	cause error
+	msg   string
+	code  string
+}
+
+func (w *withPublic) Error() string { return errorText(w.cause) }
+func (w *withPublic) Cause() error  { return w.cause }
+
+func (w *withPublic) layerMessage() (string, bool) { return "", false }
+func (w *withPublic) toJSONLayer(l *jsonLayer)     { l.Type = "public" }
+
+func (w *withPublic) publicAnnotation() (msg, code string, ok bool) {
+	return w.msg, w.code, true
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *withPublic) Unwrap() error { return w.cause }
+
+func (w *withPublic) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			formatCause(s, w.Cause())
+			io.WriteString(s, "\n")
+			io.WriteString(s, publicFields(w.msg, w.code).String())
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
+
+// publicFields returns the public message and code as they are printed
+// under %+v.
+func publicFields(msg, code string) Fields {
+	f := Fields{"public": msg}
+	if code != "" {
+		f["public_code"] = code
+	}
+	return f
+}
+
+// PublicMessage returns the public message of the outermost error in err's
+// chain of causes annotated by WithPublicMessage or WithPublicCode, or
+// DefaultPublicMessage if there is none. If err is nil, PublicMessage
+// returns "".
+func PublicMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	if msg, ok := LookupPublicMessage(err); ok {
+		return msg
+	}
+	return DefaultPublicMessage
+}
+
+// PublicCode returns the public code of the outermost error in err's chain of
+// causes annotated by WithPublicCode, or "" if there is none.
+func PublicCode(err error) string {
+	for ; err != nil; err = unwrapOnce(err) {
+		if _, code, ok := publicOf(err); ok && code != "" {
+			return code
+		}
+	}
+	return ""
+}
+
+// LookupPublicMessage returns the public message of the outermost error in
+// err's chain of causes annotated by WithPublicMessage or WithPublicCode, and
+// reports whether there is one. Unlike PublicMessage, it does not fall back
+// to DefaultPublicMessage, for callers which leave the message out instead.
+func LookupPublicMessage(err error) (string, bool) {
+	for ; err != nil; err = unwrapOnce(err) {
+		if msg, _, ok := publicOf(err); ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// publicAnnotator is implemented by errors which may be annotated by
+// WithPublicMessage or WithPublicCode.
+type publicAnnotator interface {
+	publicAnnotation() (msg, code string, ok bool)
+}
+
+// publicOf returns the public message and code err itself carries, if any.
+func publicOf(err error) (msg, code string, ok bool) {
+	if p, ok := err.(publicAnnotator); ok {
+		return p.publicAnnotation()
+	}
+	return "", "", false
+}
@@ -0,0 +1,76 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestWithPublicNil(t *testing.T) {
+	if got := WithPublicMessage(nil, "public"); got != nil {
+		t.Errorf("WithPublicMessage(nil): got %#v, expected nil", got)
+	}
+	if got := WithPublicCode(nil, "code", "public"); got != nil {
+		t.Errorf("WithPublicCode(nil): got %#v, expected nil", got)
+	}
+}
+
+func TestPublicMessage(t *testing.T) {
+	tests := []struct {
+		err  error
+		msg  string
+		code string
+	}{
+		{nil, "", ""},
+		{io.EOF, DefaultPublicMessage, ""},
+		{Wrap(io.EOF, "read users table"), DefaultPublicMessage, ""},
+		{WithPublicMessage(io.EOF, "try again later"), "try again later", ""},
+		{
+			Wrap(WithPublicCode(io.EOF, "user_not_found", "no such user"), "query users table"),
+			"no such user", "user_not_found",
+		},
+		{
+			WithPublicMessage(WithPublicCode(io.EOF, "user_not_found", "no such user"), "account closed"),
+			"account closed", "user_not_found",
+		},
+	}
+
+	for i, tt := range tests {
+		if got := PublicMessage(tt.err); got != tt.msg {
+			t.Errorf("test %d: PublicMessage(%v): got %q, want %q", i+1, tt.err, got, tt.msg)
+		}
+		if got := PublicCode(tt.err); got != tt.code {
+			t.Errorf("test %d: PublicCode(%v): got %q, want %q", i+1, tt.err, got, tt.code)
+		}
+	}
+}
+
+func TestWithPublicFormat(t *testing.T) {
+	err := WithPublicCode(WithMessage(io.EOF, "read users table"), "unavailable", "try again later")
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%s", "read users table: EOF"},
+		{"%v", "read users table: EOF"},
+		{"%q", `"read users table: EOF"`},
+		{"%+v", "EOF\nread users table\npublic=\"try again later\" public_code=unavailable"},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprintf(tt.format, err); got != tt.want {
+			t.Errorf("fmt.Sprintf(%q): got %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestLookupPublicMessage(t *testing.T) {
+	if msg, ok := LookupPublicMessage(Wrap(io.EOF, "read users table")); ok {
+		t.Errorf("LookupPublicMessage(no public message): got %q, true, want false", msg)
+	}
+	msg, ok := LookupPublicMessage(Wrap(WithPublicMessage(io.EOF, DefaultPublicMessage), "read"))
+	if msg != DefaultPublicMessage || !ok {
+		t.Errorf("LookupPublicMessage(): got %q, %t, want %q, true", msg, ok, DefaultPublicMessage)
+	}
+}
@@ -0,0 +1,150 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// Redacted is the text with which sensitive values are printed.
+const Redacted = "[REDACTED]"
+
+// Sensitive holds a value, such as a password, a token or an email address,
+// which must not appear in error messages or logs. It is printed as Redacted
+// with every verb, and marshalled to JSON and logged by log/slog as Redacted
+// too, so that it may be passed to Errorf, Wrapf and WithMessagef as an
+// argument, or set as the value of a field, without being revealed.
+//
+// The value is only returned by Reveal, and by Unredacted as part of the
+// message of an error it was formatted into.
+type Sensitive struct {
+	v interface{}
+}
+
+// Secret returns v marked as sensitive.
+func Secret(v interface{}) Sensitive { return Sensitive{v} }
+
+// Reveal returns the sensitive value.
+func (s Sensitive) Reveal() interface{} { return s.v }
+
+func (s Sensitive) String() string { return Redacted }
+
+// Format prints Redacted, whatever the verb.
+func (s Sensitive) Format(st fmt.State, verb rune) { io.WriteString(st, Redacted) }
+
+// MarshalJSON implements json.Marshaler, marshalling the value as Redacted.
+func (s Sensitive) MarshalJSON() ([]byte, error) { return json.Marshal(Redacted) }
+
+// Unredacted returns the message of err as Error does, but with the values of
+// the Sensitive arguments with which its messages were formatted revealed,
+// and without applying the current Scrubbers to them. It is meant for
+// privileged uses only, such as a debugging session; messages of errors from
+// other packages are returned as they are.
+func Unredacted(err error) string {
+	if err == nil {
+		return ""
+	}
+	if u, ok := err.(unredacter); ok {
+		return u.unredacted()
+	}
+	if _, ok := layerMessage(err); !ok {
+		// Annotations which add no message of their own have that of
+		// their cause.
+		if cause := unwrapOnce(err); cause != nil {
+			return Unredacted(cause)
+		}
+	}
+	return err.Error()
+}
+
+// unredacter is implemented by the errors of this package whose messages
+// are, or are made of, messages which may be formatted with Sensitive
+// arguments.
+type unredacter interface {
+	unredacted() string
+}
+
+// A Scrubber removes sensitive data, such as the values of SQL parameters,
+// from the messages of errors and the values of their fields, for programs
+// which cannot mark every sensitive value with Secret.
+type Scrubber interface {
+	// Scrub returns s with any sensitive data it holds masked.
+	Scrub(s string) string
+}
+
+// RegexpScrubber returns a Scrubber which replaces the matches of re with
+// repl, as re.ReplaceAllString does.
+func RegexpScrubber(re *regexp.Regexp, repl string) Scrubber {
+	return &regexpScrubber{re, repl}
+}
+
+type regexpScrubber struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+func (s *regexpScrubber) Scrub(str string) string { return s.re.ReplaceAllString(str, s.repl) }
+
+// scrubbersValue wraps the current Scrubbers, as atomic.Value cannot hold
+// nil.
+type scrubbersValue struct {
+	scrubbers []Scrubber
+}
+
+var (
+	scrubbersMu sync.Mutex // serialises SetScrubbers
+	scrubbers   atomic.Value
+)
+
+// SetScrubbers sets the Scrubbers applied, in turn, to the messages of the
+// errors returned by this package, including those of the errors of other
+// packages they wrap, and to the values of their fields whenever they are
+// formatted, marshalled to JSON or logged by log/slog, and returns the
+// previous ones. A field value which a Scrubber changes is marshalled and
+// logged as its scrubbed %v form. By default there are none.
+func SetScrubbers(s ...Scrubber) []Scrubber {
+	scrubbersMu.Lock()
+	defer scrubbersMu.Unlock()
+	prev, _ := scrubbers.Load().(scrubbersValue)
+	scrubbers.Store(scrubbersValue{append([]Scrubber(nil), s...)})
+	return prev.scrubbers
+}
+
+// scrubValue returns v, the value of a field, as it is to be marshalled or
+// logged: its %v form scrubbed by the current Scrubbers if they change it, or
+// else v itself. Sensitive values redact themselves and are returned as they
+// are.
+func scrubValue(v interface{}) interface{} {
+	if _, ok := v.(Sensitive); ok {
+		return v
+	}
+	if s, _ := scrubbers.Load().(scrubbersValue); len(s.scrubbers) == 0 {
+		return v
+	}
+	str := fmt.Sprint(v)
+	if scrubbed := scrub(str); scrubbed != str {
+		return scrubbed
+	}
+	return v
+}
+
+// errorText returns the message of err: scrubbed by the current Scrubbers if
+// err is not an error of this package, whose messages are scrubbed already.
+func errorText(err error) string {
+	if _, ok := err.(layerMessager); ok {
+		return err.Error()
+	}
+	return scrub(err.Error())
+}
+
+// scrub applies the current Scrubbers to s.
+func scrub(s string) string {
+	v, _ := scrubbers.Load().(scrubbersValue)
+	for _, sc := range v.scrubbers {
+		s = sc.Scrub(s)
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	s := Secret("hunter2")
+
+	for _, format := range []string{"%s", "%v", "%+v", "%#v", "%q", "%d", "%x"} {
+		if got := fmt.Sprintf(format, s); got != Redacted {
+			t.Errorf("fmt.Sprintf(%q, Secret()): got %q, want %q", format, got, Redacted)
+		}
+	}
+	if got := s.Reveal(); got != "hunter2" {
+		t.Errorf("Reveal(): got %v, want %q", got, "hunter2")
+	}
+	if got, _ := json.Marshal(Fields{"password": s}); string(got) != `{"password":"[REDACTED]"}` {
+		t.Errorf("json.Marshal(): got %s, want the value redacted", got)
+	}
+}
+
+func TestSecretMessages(t *testing.T) {
+	email := Secret("alice@example.com")
+	tests := []struct {
+		err        error
+		want       string
+		unredacted string
+	}{{
+		Errorf("no user %s", email),
+		"no user [REDACTED]",
+		"no user alice@example.com",
+	}, {
+		Wrapf(io.EOF, "read mail of %v", email),
+		"read mail of [REDACTED]: EOF",
+		"read mail of alice@example.com: EOF",
+	}, {
+		WithFields(WithMessagef(Errorf("token %q", Secret("t0k")), "user %s", email), Fields{"k": "v"}),
+		"user [REDACTED]: token [REDACTED]",
+		`user alice@example.com: token "t0k"`,
+	}, {
+		Join(Errorf("a %s", email), io.EOF),
+		"a [REDACTED]\nEOF",
+		"a alice@example.com\nEOF",
+	}, {
+		nil, "", "",
+	}}
+
+	for i, tt := range tests {
+		if tt.err != nil {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("test %d: Error(): got %q, want %q", i+1, got, tt.want)
+			}
+			if got := fmt.Sprintf("%+v", tt.err); strings.Contains(got, "alice") {
+				t.Errorf("test %d: %%+v: got %q, want the secret redacted", i+1, got)
+			}
+		}
+		if got := Unredacted(tt.err); got != tt.unredacted {
+			t.Errorf("test %d: Unredacted(): got %q, want %q", i+1, got, tt.unredacted)
+		}
+	}
+
+	if got := fmt.Sprintf("%+v", WithValue(io.EOF, "password", Secret("hunter2"))); got != "EOF\npassword=[REDACTED]" {
+		t.Errorf("%%+v of fields: got %q, want the value redacted", got)
+	}
+}
+
+func TestScrubbers(t *testing.T) {
+	defer SetScrubbers(SetScrubbers(
+		RegexpScrubber(regexp.MustCompile(`[\w.]+@[\w.]+`), "<email>"),
+		RegexpScrubber(regexp.MustCompile(`token=\w+`), "token=***"),
+	)...)
+
+	err := WithValue(Wrap(Errorf("no user bob@example.com"), "login token=abc"), "from", "carol@example.com")
+
+	if got, want := err.Error(), "login token=***: no user <email>"; got != want {
+		t.Errorf("Error(): got %q, want %q", got, want)
+	}
+	got := fmt.Sprintf("%+v", err)
+	for _, leak := range []string{"bob@", "carol@", "abc"} {
+		if strings.Contains(got, leak) {
+			t.Errorf("%%+v: got %q, want %q scrubbed", got, leak)
+		}
+	}
+	if got, want := Unredacted(err), "login token=abc: no user bob@example.com"; got != want {
+		t.Errorf("Unredacted(): got %q, want %q", got, want)
+	}
+	b, _ := json.Marshal(WithFields(err, Fields{"n": 1, "password": Secret("hunter2")}))
+	for _, leak := range []string{"bob@", "carol@", "abc", "hunter2"} {
+		if strings.Contains(string(b), leak) {
+			t.Errorf("json.Marshal(): got %s, want %q scrubbed", b, leak)
+		}
+	}
+	var doc jsonError
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.Layers[1].Fields["from"]; got != "<email>" {
+		t.Errorf("json.Marshal(): got field from=%v, want it scrubbed", got)
+	}
+	if got := doc.Layers[0].Fields["n"]; got != float64(1) {
+		t.Errorf("json.Marshal(): got field n=%#v, want it as it is", got)
+	}
+
+	if prev := SetScrubbers(); len(prev) != 2 {
+		t.Errorf("SetScrubbers(): got %d previous scrubbers, want 2", len(prev))
+	}
+	if got, want := err.Error(), "login token=abc: no user bob@example.com"; got != want {
+		t.Errorf("Error() without scrubbers: got %q, want %q", got, want)
+	}
+}
+
+func TestScrubbersForeignCause(t *testing.T) {
+	defer SetScrubbers(SetScrubbers(
+		RegexpScrubber(regexp.MustCompile(`password=\S+`), "password=***"),
+	)...)
+
+	cause := fmt.Errorf("db: password=hunter2")
+	tests := []error{
+		Wrap(cause, "query"),
+		WithStack(cause),
+		WithCode(cause, CodeInternal),
+		WithMessage(cause, "query"),
+		Join(io.EOF, cause),
+		WithFields(Join(cause), Fields{"n": 1}),
+	}
+
+	for i, err := range tests {
+		for _, format := range []string{"%s", "%v", "%q", "%+v"} {
+			if got := fmt.Sprintf(format, err); strings.Contains(got, "hunter2") {
+				t.Errorf("test %d: %s: got %q, want the password scrubbed", i+1, format, got)
+			}
+		}
+		b, _ := json.Marshal(err)
+		if strings.Contains(string(b), "hunter2") {
+			t.Errorf("test %d: json.Marshal(): got %s, want the password scrubbed", i+1, b)
+		}
+	}
+
+	var doc jsonError
+	b, _ := json.Marshal(Wrap(cause, "query"))
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.Message, "query: db: password=***"; got != want {
+		t.Errorf("json.Marshal(): got message %q, want %q", got, want)
+	}
+	if got, want := doc.Cause.Message, "db: password=***"; got != want {
+		t.Errorf("json.Marshal(): got cause message %q, want %q", got, want)
+	}
+	if got, want := Unredacted(Wrap(cause, "query")), "query: db: password=hunter2"; got != want {
+		t.Errorf("Unredacted(): got %q, want %q", got, want)
+	}
+}
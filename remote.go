@@ -0,0 +1,390 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sentinels holds the errors registered with Register.
+var sentinels = struct {
+	sync.RWMutex
+	byName map[string]error
+}{byName: make(map[string]error)}
+
+// Register records err, typically a package level sentinel error such as
+// io.EOF, under name. When an error whose chain contains err is marshalled to
+// JSON the name is recorded with it, and when UnmarshalError decodes it in a
+// process which registered the same name, Is reports the decoded error as
+// matching err. A decoded chain which ends in err ends in err itself, so that
+// comparing its Cause with err works too.
+//
+// Registering a name again replaces the error registered under it. Register
+// panics if err is nil or of a type whose values cannot be compared.
+func Register(name string, err error) {
+	if err == nil || !reflect.TypeOf(err).Comparable() {
+		panic("errors: Register of nil or incomparable error")
+	}
+	sentinels.Lock()
+	defer sentinels.Unlock()
+	sentinels.byName[name] = err
+}
+
+// sentinelName returns the name err was registered under, if any.
+func sentinelName(err error) string {
+	sentinels.RLock()
+	defer sentinels.RUnlock()
+	for name, sentinel := range sentinels.byName {
+		if equal(sentinel, err) {
+			return name
+		}
+	}
+	return ""
+}
+
+// equal reports whether a == b, and false, rather than panicking, if they are
+// values of a comparable type which hold values that are not, such as a
+// struct with an interface field holding a slice.
+func equal(a, b error) (eq bool) {
+	defer func() {
+		if recover() != nil {
+			eq = false
+		}
+	}()
+	return a == b
+}
+
+// sentinel returns the error registered under name, if any.
+func sentinel(name string) error {
+	if name == "" {
+		return nil
+	}
+	sentinels.RLock()
+	defer sentinels.RUnlock()
+	return sentinels.byName[name]
+}
+
+// UnmarshalError decodes data, the JSON form of an error produced by the
+// MarshalJSON methods of this package's errors, and stores the error it
+// describes in the value pointed to by target. If data is null, the error
+// stored is nil.
+//
+// The decoded error reproduces the original's chain of causes: its Error,
+// Cause, Unwrap and %+v output match those of the original, with the stack
+// traces recorded by the remote process printed from their resolved function,
+// file and line, as its program counters are meaningless in this one. Errors
+// registered with Register are matched by Is.
+//
+// The JSON form is the package's only encoding for carrying errors between
+// processes. Transports with their own error types, such as the details of a
+// gRPC status, can carry it as an opaque payload, with the encoding into and
+// out of their types left to packages which depend on them.
+func UnmarshalError(data []byte, target *error) error {
+	var doc *jsonError
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc == nil {
+		*target = nil
+		return nil
+	}
+	*target = fromJSON(*doc)
+	return nil
+}
+
+// fromJSON returns the error described by doc.
+func fromJSON(doc jsonError) error {
+	if len(doc.Layers) == 0 {
+		return &remoteError{typ: doc.Cause.Type, msg: doc.Message}
+	}
+	var err error
+	for i := len(doc.Layers) - 1; i >= 0; i-- {
+		err = fromJSONLayer(doc.Layers[i], err)
+	}
+	return err
+}
+
+// fromJSONLayer returns the error described by l, wrapping cause.
+func fromJSONLayer(l jsonLayer, cause error) error {
+	st := remoteStack{frames: l.Stack, more: l.MoreFrames}
+	if l.Type == "join" {
+		errs := make([]error, len(l.Errors))
+		for i, doc := range l.Errors {
+			errs[i] = fromJSON(doc)
+		}
+		return &remoteJoin{errs: errs, stack: st}
+	}
+
+	r := remoteError{
+		typ:      l.Type,
+		msg:      l.Message,
+		fields:   l.Fields,
+		stack:    st,
+		sentinel: sentinel(l.Sentinel),
+	}
+	if l.Code != "" {
+		r.code = parseCode(l.Code)
+	}
+	if l.Retryable != nil {
+		r.retry = &remoteRetry{retryable: *l.Retryable}
+		r.retry.after, _ = time.ParseDuration(l.RetryAfter)
+	}
+	r.public.msg, r.public.code = l.PublicMessage, l.PublicCode
+	for _, doc := range l.Errors {
+		r.errs = append(r.errs, fromJSON(doc))
+	}
+	if cause == nil {
+		if r.sentinel != nil {
+			return r.sentinel
+		}
+		return &r
+	}
+	return &remoteWrapper{remoteError: r, cause: cause}
+}
+
+// remoteStack is a stack trace decoded from its JSON form.
+type remoteStack struct {
+	frames []FrameInfo
+	more   int
+}
+
+func (s remoteStack) Format(st fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if st.Flag('+') {
+			formatFrames(st, s.frames, s.more)
+		}
+	}
+}
+
+// remoteError is an error decoded from its JSON form which has no cause.
+type remoteError struct {
+	typ      string
+	msg      string
+	fields   Fields
+	stack    remoteStack
+	sentinel error
+	code     ErrorCode
+	retry    *remoteRetry
+	public   struct{ msg, code string }
+
+	// errs are the errors of the earlier attempts of an error returned
+	// by Retry.
+	errs []error
+}
+
+// remoteRetry is the decoded form of the annotations of WithRetryable and
+// WithRetryAfter.
+type remoteRetry struct {
+	retryable bool
+	after     time.Duration
+}
+
+func (r *remoteError) Error() string { return r.msg }
+
+func (r *remoteError) layerMessage() (string, bool) { return r.msg, r.msg != "" }
+
+func (r *remoteError) toJSONLayer(l *jsonLayer) {
+	l.Type = r.typ
+	l.Stack, l.MoreFrames = r.stack.frames, r.stack.more
+	if r.sentinel != nil {
+		l.Sentinel = sentinelName(r.sentinel)
+	}
+	l.Errors = toJSONErrors(r.errs)
+}
+
+func (r *remoteError) frameInfos() ([]FrameInfo, int) { return r.stack.frames, r.stack.more }
+
+// Fields returns the fields the error was marshalled with.
+func (r *remoteError) Fields() Fields { return r.fields }
+
+func (r *remoteError) retryAnnotation() (retryable bool, after time.Duration, ok bool) {
+	if r.retry == nil {
+		return false, 0, false
+	}
+	return r.retry.retryable, r.retry.after, true
+}
+
+func (r *remoteError) publicAnnotation() (msg, code string, ok bool) {
+	return r.public.msg, r.public.code, r.typ == "public"
+}
+
+// Is reports whether target is the registered error this error was
+// marshalled from, or the code it was annotated with.
+func (r *remoteError) Is(target error) bool {
+	if c, ok := target.(ErrorCode); ok && r.code != CodeOK {
+		return c == r.code
+	}
+	return r.sentinel != nil && equal(r.sentinel, target)
+}
+
+// ErrorCode returns the code the error was annotated with, if any.
+func (r *remoteError) ErrorCode() ErrorCode { return r.code }
+
+func (r *remoteError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, r.msg)
+			if len(r.fields) > 0 {
+				io.WriteString(s, "\n")
+				io.WriteString(s, r.fields.String())
+			}
+			if r.code != CodeOK {
+				io.WriteString(s, "\ncode=")
+				io.WriteString(s, r.code.String())
+			}
+			r.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, r.msg)
+	case 'q':
+		fmt.Fprintf(s, "%q", r.msg)
+	}
+}
+
+// remoteWrapper is an error decoded from its JSON form which wraps another.
+type remoteWrapper struct {
+	remoteError
+	cause error
+}
+
+func (w *remoteWrapper) Error() string {
+	switch w.typ {
+	case "message", "attempts":
+		return w.msg + ": " + errorText(w.cause)
+	case "stack", "fields", "code", "spawn", "retry", "public":
+		return errorText(w.cause)
+	}
+	return w.msg
+}
+
+func (w *remoteWrapper) Cause() error { return w.cause }
+
+func (w *remoteWrapper) attempts() ([]error, bool) {
+	if w.typ != "attempts" {
+		return nil, false
+	}
+	return append(w.errs[:len(w.errs):len(w.errs)], w.cause), true
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *remoteWrapper) Unwrap() error { return w.cause }
+
+func (w *remoteWrapper) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			switch w.typ {
+			case "message":
+				formatCause(s, w.cause)
+				io.WriteString(s, "\n")
+				io.WriteString(s, w.msg)
+			case "fields":
+				formatCause(s, w.cause)
+				io.WriteString(s, "\n")
+				io.WriteString(s, w.fields.String())
+			case "code":
+				formatCause(s, w.cause)
+				io.WriteString(s, "\ncode=")
+				io.WriteString(s, w.code.String())
+			case "retry":
+				formatCause(s, w.cause)
+				// The layer came from another process, which
+				// may have left out the annotation.
+				if w.retry == nil {
+					break
+				}
+				io.WriteString(s, "\nretryable=")
+				io.WriteString(s, strconv.FormatBool(w.retry.retryable))
+				if w.retry.after > 0 {
+					io.WriteString(s, " retry_after=")
+					io.WriteString(s, w.retry.after.String())
+				}
+			case "public":
+				formatCause(s, w.cause)
+				io.WriteString(s, "\n")
+				io.WriteString(s, publicFields(w.public.msg, w.public.code).String())
+			case "attempts":
+				formatCause(s, w.cause)
+				io.WriteString(s, "\n")
+				io.WriteString(s, w.msg)
+				if len(w.errs) > 0 {
+					io.WriteString(s, "\n")
+					formatList(s, "earlier attempts:", w.errs)
+				}
+			case "spawn":
+				formatSpawn(s, w.cause, w.stack.frames, w.stack.more)
+				return
+			case "stack":
+				formatCause(s, w.cause)
+			default:
+				// Errors from other packages are printed as
+				// their message, as fmt prints them.
+				io.WriteString(s, w.msg)
+			}
+			w.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		if w.typ == "message" {
+			// As withMessage does.
+			io.WriteString(s, w.Error())
+			return
+		}
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
+
+// remoteJoin is an error decoded from the JSON form of one returned by Join.
+type remoteJoin struct {
+	errs  []error
+	stack remoteStack
+}
+
+func (m *remoteJoin) Error() string { return joinMessages(m.errs) }
+
+func (m *remoteJoin) layerMessage() (string, bool)   { return "", false }
+func (m *remoteJoin) frameInfos() ([]FrameInfo, int) { return m.stack.frames, m.stack.more }
+
+func (m *remoteJoin) toJSONLayer(l *jsonLayer) {
+	l.Type = "join"
+	l.Errors = toJSONErrors(m.errs)
+}
+
+// Unwrap provides compatibility for Go 1.20 error trees.
+func (m *remoteJoin) Unwrap() []error { return m.errs }
+
+func (m *remoteJoin) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			formatErrors(s, m.errs)
+			m.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, m.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", m.Error())
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *remoteError) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(r)) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *remoteWrapper) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(w)) }
+
+// MarshalJSON implements json.Marshaler.
+func (m *remoteJoin) MarshalJSON() ([]byte, error) { return json.Marshal(toJSON(m)) }
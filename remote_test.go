@@ -0,0 +1,165 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var errRemoteSentinel = New("remote sentinel")
+
+func init() {
+	Register("github.com/pkg/errors.errRemoteSentinel", errRemoteSentinel)
+}
+
+// roundTrip marshals err to JSON and decodes it back.
+func roundTrip(t *testing.T, err error) (error, []byte) {
+	t.Helper()
+	data, e := json.Marshal(err)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var got error
+	if e := UnmarshalError(data, &got); e != nil {
+		t.Fatalf("UnmarshalError(%s): %v", data, e)
+	}
+	return got, data
+}
+
+func TestUnmarshalError(t *testing.T) {
+	tests := []error{
+		New("error"),
+		Wrap(io.EOF, "read failed"),
+		WithFields(Wrapf(New("error"), "wrapped %d", 1), Fields{"user_id": "u1"}),
+		WithMessage(fmt.Errorf("wrap: %w", WithStack(io.EOF)), "message"),
+		Join(New("error"), WithMessage(io.EOF, "message")),
+		WithStackDepth(io.EOF, 1),
+		WithCode(Wrap(io.EOF, "read failed"), CodeUnavailable),
+		<-Go(func() error { return Wrap(io.EOF, "read failed") }),
+		WithRetryable(WithRetryAfter(io.EOF, time.Second), false),
+		Retry(context.Background(), RetryPolicy{Attempts: 2}, func(context.Context) error {
+			return WithRetryable(io.EOF, true)
+		}),
+		WithPublicCode(Wrap(io.EOF, "read failed"), "unavailable", "try again later"),
+		Raise(errSentinel),
+		Wrap(errUserNotFound.New("alice"), "lookup"),
+	}
+
+	for i, err := range tests {
+		got, data := roundTrip(t, err)
+		for _, format := range []string{"%s", "%v", "%q", "%+v"} {
+			if got, want := fmt.Sprintf(format, got), fmt.Sprintf(format, err); got != want {
+				t.Errorf("test %d: fmt.Sprintf(%q, decoded):\n got: %q\nwant: %q", i+1, format, got, want)
+			}
+		}
+		if got, want := AllFields(got), AllFields(err); !reflect.DeepEqual(got, want) {
+			t.Errorf("test %d: AllFields(decoded): got %v, want %v", i+1, got, want)
+		}
+		if again, _ := json.Marshal(got); string(again) != string(data) {
+			t.Errorf("test %d: json.Marshal(decoded):\n got %s\nwant %s", i+1, again, data)
+		}
+	}
+}
+
+func TestUnmarshalErrorCause(t *testing.T) {
+	got, _ := roundTrip(t, Wrap(WithMessage(errRemoteSentinel, "inner"), "outer"))
+
+	if Cause(got) != errRemoteSentinel {
+		t.Errorf("Cause(decoded): got %#v, want the registered sentinel", Cause(got))
+	}
+	if got, want := got.Error(), "outer: inner: remote sentinel"; got != want {
+		t.Errorf("decoded.Error(): got %q, want %q", got, want)
+	}
+	if Unwrap(got) == nil {
+		t.Errorf("Unwrap(decoded): got nil, want the decoded cause")
+	}
+
+	got, _ = roundTrip(t, Wrap(io.EOF, "outer"))
+	if got, ok := Cause(got).(*remoteError); !ok || got.Error() != "EOF" {
+		t.Errorf("Cause(decoded): got %#v, want a remote error EOF", got)
+	}
+}
+
+func TestUnmarshalErrorIs(t *testing.T) {
+	sentinel := &remoteError{msg: "wrapped sentinel"}
+	Register("github.com/pkg/errors.TestUnmarshalErrorIs", sentinel)
+
+	got, _ := roundTrip(t, &withStack{sentinel, callers()})
+	if !Is(got, sentinel) {
+		t.Errorf("Is(decoded, sentinel): got false, want true")
+	}
+	if Is(got, errRemoteSentinel) {
+		t.Errorf("Is(decoded, errRemoteSentinel): got true, want false")
+	}
+}
+
+func TestUnmarshalErrorNull(t *testing.T) {
+	got := io.EOF
+	if err := UnmarshalError([]byte("null"), &got); err != nil || got != nil {
+		t.Errorf("UnmarshalError(null): got %v, %v, want nil, nil", got, err)
+	}
+	if err := UnmarshalError([]byte("{"), &got); err == nil {
+		t.Errorf("UnmarshalError({): got nil, want error")
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	for _, err := range []error{nil, multiErrorValue{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%#v): did not panic", err)
+				}
+			}()
+			Register("bad", err)
+		}()
+	}
+}
+
+type multiErrorValue struct{ errs []error }
+
+func (multiErrorValue) Error() string { return "incomparable" }
+
+func TestUnmarshalErrorMissingRetry(t *testing.T) {
+	var got error
+	data := `{"layers":[{"type":"retry"},{"type":"x","message":"m"}]}`
+	if err := UnmarshalError([]byte(data), &got); err != nil {
+		t.Fatalf("UnmarshalError(%s): %v", data, err)
+	}
+	if got, want := fmt.Sprintf("%+v", got), "m"; got != want {
+		t.Errorf("fmt.Sprintf(%%+v, decoded): got %q, want %q", got, want)
+	}
+	if IsRetryable(got) {
+		t.Errorf("IsRetryable(decoded): got true, want false")
+	}
+}
+
+// interfaceError is an error of a comparable type whose values may not be.
+type interfaceError struct{ v interface{} }
+
+func (interfaceError) Error() string { return "interface" }
+
+func TestRegisterIncomparableValue(t *testing.T) {
+	Register("github.com/pkg/errors.interfaceError", interfaceError{1})
+
+	err := Wrap(interfaceError{[]int{1}}, "wrapped")
+	got, _ := roundTrip(t, err)
+	if Is(got, interfaceError{1}) {
+		t.Errorf("Is(decoded, interfaceError{1}): got true, want false")
+	}
+	if Is(got, interfaceError{[]int{1}}) {
+		t.Errorf("Is(decoded, interfaceError{[]int{1}}): got true, want false")
+	}
+
+	got, _ = roundTrip(t, Wrap(interfaceError{1}, "wrapped"))
+	if !Is(got, interfaceError{1}) {
+		t.Errorf("Is(decoded, interfaceError{1}): got false, want true")
+	}
+	if Is(got, interfaceError{[]int{1}}) {
+		t.Errorf("Is(decoded, interfaceError{[]int{1}}): got true, want false")
+	}
+}
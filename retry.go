@@ -0,0 +1,341 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// WithRetryable annotates err as one which may, or may not, succeed if the
+// operation which returned it is retried, as reported by IsRetryable.
+// If err is nil, WithRetryable returns nil.
+func WithRetryable(err error, retryable bool) error {
+	if err == nil {
+		return nil
+	}
+	return &withRetry{
+		cause:     err,
+		retryable: retryable,
+	}
+}
+
+// WithRetryAfter annotates err as one which may succeed if the operation
+// which returned it is retried after d, as reported by IsRetryable and
+// RetryAfter.
+// If err is nil, WithRetryAfter returns nil.
+func WithRetryAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &withRetry{
+		cause:     err,
+		retryable: true,
+		after:     d,
+	}
+}
+
+type withRetry struct {
+	cause     error
+	retryable bool
+	after     time.Duration
+}
+
+func (w *withRetry) Error() string { return errorText(w.cause) }
+func (w *withRetry) Cause() error  { return w.cause }
+
+func (w *withRetry) layerMessage() (string, bool) { return "", false }
+func (w *withRetry) toJSONLayer(l *jsonLayer)     { l.Type = "retry" }
+
+func (w *withRetry) retryAnnotation() (retryable bool, after time.Duration, ok bool) {
+	return w.retryable, w.after, true
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *withRetry) Unwrap() error { return w.cause }
+
+func (w *withRetry) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			formatCause(s, w.Cause())
+			io.WriteString(s, "\nretryable=")
+			io.WriteString(s, strconv.FormatBool(w.retryable))
+			if w.after > 0 {
+				io.WriteString(s, " retry_after=")
+				io.WriteString(s, w.after.String())
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
+
+// IsRetryable reports whether the operation which returned err may succeed
+// if it is retried. This is decided by the outermost error in err's chain of
+// causes which is either annotated by WithRetryable or WithRetryAfter,
+// context.DeadlineExceeded or context.Canceled, or implements one of
+//
+//	type temporary interface {
+//	        Temporary() bool
+//	}
+//
+//	type timeout interface {
+//	        Timeout() bool
+//	}
+//
+// as net.Error does. Such an error decides even if both methods it implements
+// report false, as the errors of the standard library which implement them
+// report those of their causes. Errors which time out are retryable, and
+// context.Canceled is not. IsRetryable returns false if no error in the
+// chain decides.
+func IsRetryable(err error) bool {
+	type temporary interface {
+		Temporary() bool
+	}
+	type timeout interface {
+		Timeout() bool
+	}
+
+	for ; err != nil; err = unwrapOnce(err) {
+		if retryable, _, ok := retryOf(err); ok {
+			return retryable
+		}
+		tmp, isTemporary := err.(temporary)
+		tmo, isTimeout := err.(timeout)
+		if isTemporary || isTimeout {
+			return isTemporary && tmp.Temporary() || isTimeout && tmo.Timeout()
+		}
+		switch err {
+		case context.DeadlineExceeded:
+			return true
+		case context.Canceled:
+			return false
+		}
+	}
+	return false
+}
+
+// RetryAfter returns the delay of the outermost error in err's chain of
+// causes annotated by WithRetryAfter.
+func RetryAfter(err error) (time.Duration, bool) {
+	for ; err != nil; err = unwrapOnce(err) {
+		if _, after, ok := retryOf(err); ok && after > 0 {
+			return after, true
+		}
+	}
+	return 0, false
+}
+
+// retryAnnotator is implemented by errors which may be annotated by
+// WithRetryable or WithRetryAfter.
+type retryAnnotator interface {
+	retryAnnotation() (retryable bool, after time.Duration, ok bool)
+}
+
+// retryOf returns the annotation of WithRetryable or WithRetryAfter which
+// err itself carries, if any.
+func retryOf(err error) (retryable bool, after time.Duration, ok bool) {
+	if r, ok := err.(retryAnnotator); ok {
+		return r.retryAnnotation()
+	}
+	return false, 0, false
+}
+
+// DefaultRetryAttempts is the number of times Retry calls its function when
+// RetryPolicy.Attempts is not set.
+const DefaultRetryAttempts = 3
+
+// RetryPolicy sets how often, and how soon, Retry retries a failed call.
+type RetryPolicy struct {
+	// Attempts is the maximum number of calls, including the first.
+	// If it is zero or less, DefaultRetryAttempts are made.
+	Attempts int
+
+	// Delay is the time to wait before the second call. The delay is
+	// doubled after each further call, up to MaxDelay if it is set.
+	// Errors with a RetryAfter delay are retried after that delay
+	// instead.
+	Delay    time.Duration
+	MaxDelay time.Duration
+}
+
+// Retry calls fn until it returns nil, returns an error which IsRetryable
+// reports cannot be retried, or has been called as many times as policy
+// allows, waiting between calls as policy sets. It stops early, without
+// calling fn again, if ctx is done.
+//
+// If every call failed, Retry returns an error whose Cause is the error of
+// the last call, annotated with the number of calls made and the errors of
+// each, as returned by Attempts, and with the stack trace at the point Retry
+// was called. If Retry stopped early because ctx is done, the error also
+// matches ctx.Err() with Is, and is not retryable. If ctx is done before
+// the first call, Retry does not call fn at all, and returns ctx.Err()
+// annotated as not retryable and with the stack trace.
+func Retry(ctx context.Context, policy RetryPolicy, fn func(context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return WithStack(WithRetryable(err, false))
+	}
+
+	attempts := policy.Attempts
+	if attempts <= 0 {
+		attempts = DefaultRetryAttempts
+	}
+	delay := policy.Delay
+
+	var errs []error
+	var ctxErr error
+	for {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+		if len(errs) >= attempts || !IsRetryable(err) {
+			break
+		}
+
+		wait := delay
+		if d, ok := RetryAfter(err); ok {
+			wait = d
+		}
+		delay = backoff(delay, policy.MaxDelay)
+		if !sleep(ctx, wait) {
+			ctxErr = ctx.Err()
+			break
+		}
+	}
+
+	return &withAttempts{
+		cause:   errs[len(errs)-1],
+		earlier: errs[:len(errs)-1],
+		ctxErr:  ctxErr,
+		stack:   callers(),
+	}
+}
+
+// backoff returns the delay which follows delay: twice delay, but no more
+// than max if it is set, nor than the longest time.Duration.
+func backoff(delay, max time.Duration) time.Duration {
+	if max <= 0 {
+		max = math.MaxInt64
+	}
+	if delay > max/2 {
+		return max
+	}
+	return delay * 2
+}
+
+// sleep waits for d, or until ctx is done, and reports whether ctx is not.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Attempts returns the errors of every call made by the Retry which returned
+// err, or one of its causes, the last of them its Cause. Attempts returns nil
+// if no error in err's chain of causes was returned by Retry.
+func Attempts(err error) []error {
+	for ; err != nil; err = unwrapOnce(err) {
+		if a, ok := err.(attempter); ok {
+			if errs, ok := a.attempts(); ok {
+				return errs
+			}
+		}
+	}
+	return nil
+}
+
+// attempter is implemented by errors which may have been returned by Retry.
+type attempter interface {
+	attempts() ([]error, bool)
+}
+
+// withAttempts is an error returned by Retry after every call failed.
+type withAttempts struct {
+	cause   error
+	earlier []error
+
+	// ctxErr is the error of the context which stopped Retry, if any.
+	ctxErr error
+	*stack
+}
+
+func (w *withAttempts) Error() string { return w.msg() + ": " + errorText(w.cause) }
+func (w *withAttempts) Cause() error  { return w.cause }
+
+func (w *withAttempts) layerMessage() (string, bool) { return w.msg(), true }
+func (w *withAttempts) unredacted() string           { return w.msg() + ": " + Unredacted(w.cause) }
+
+// Is reports whether target is the error of the context which stopped
+// Retry.
+func (w *withAttempts) Is(target error) bool {
+	return w.ctxErr != nil && equal(target, w.ctxErr)
+}
+
+// retryAnnotation reports errors of a Retry stopped by its context as not
+// retryable, so that enclosing retries stop too.
+func (w *withAttempts) retryAnnotation() (retryable bool, after time.Duration, ok bool) {
+	return false, 0, w.ctxErr != nil
+}
+
+func (w *withAttempts) attempts() ([]error, bool) {
+	return append(w.earlier[:len(w.earlier):len(w.earlier)], w.cause), true
+}
+
+func (w *withAttempts) toJSONLayer(l *jsonLayer) {
+	l.Type = "attempts"
+	l.Errors = toJSONErrors(w.earlier)
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *withAttempts) Unwrap() error { return w.cause }
+
+// msg returns the message the error adds to its cause.
+func (w *withAttempts) msg() string {
+	msg := "after " + strconv.Itoa(len(w.earlier)+1) + " attempts"
+	if len(w.earlier) == 0 {
+		msg = "after 1 attempt"
+	}
+	if w.ctxErr != nil {
+		msg = w.ctxErr.Error() + " " + msg
+	}
+	return msg
+}
+
+func (w *withAttempts) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			formatCause(s, w.Cause())
+			io.WriteString(s, "\n")
+			io.WriteString(s, w.msg())
+			if len(w.earlier) > 0 {
+				io.WriteString(s, "\n")
+				formatList(s, "earlier attempts:", w.earlier)
+			}
+			w.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
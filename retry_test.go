@@ -0,0 +1,205 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestWithRetryNil(t *testing.T) {
+	if got := WithRetryable(nil, true); got != nil {
+		t.Errorf("WithRetryable(nil): got %#v, expected nil", got)
+	}
+	if got := WithRetryAfter(nil, time.Second); got != nil {
+		t.Errorf("WithRetryAfter(nil): got %#v, expected nil", got)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err   error
+		want  bool
+		after time.Duration
+	}{
+		{nil, false, 0},
+		{io.EOF, false, 0},
+		{WithRetryable(io.EOF, true), true, 0},
+		{WithRetryable(WithRetryable(io.EOF, true), false), false, 0},
+		{Wrap(WithRetryAfter(io.EOF, time.Second), "wrapped"), true, time.Second},
+		{WithRetryable(WithRetryAfter(io.EOF, time.Second), false), false, time.Second},
+		{Wrap(context.DeadlineExceeded, "wrapped"), true, 0},
+		{Wrap(context.Canceled, "wrapped"), false, 0},
+		{&net.DNSError{Err: "timeout", IsTimeout: true}, true, 0},
+		{&net.DNSError{Err: "no such host", IsNotFound: true}, false, 0},
+		{fmt.Errorf("dial: %w", &net.DNSError{IsTemporary: true}), true, 0},
+		{&timeoutError{WithRetryable(io.EOF, true)}, false, 0},
+	}
+
+	for i, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("test %d: IsRetryable(%v): got %t, want %t", i+1, tt.err, got, tt.want)
+		}
+		if got, ok := RetryAfter(tt.err); got != tt.after || ok != (tt.after > 0) {
+			t.Errorf("test %d: RetryAfter(%v): got %v, %t, want %v", i+1, tt.err, got, ok, tt.after)
+		}
+	}
+}
+
+func TestWithRetryFormat(t *testing.T) {
+	err := WithRetryAfter(io.EOF, time.Second)
+	if got, want := fmt.Sprintf("%+v", err), "EOF\nretryable=true retry_after=1s"; got != want {
+		t.Errorf("%%+v: got %q, want %q", got, want)
+	}
+	if got := fmt.Sprintf("%v", err); got != "EOF" {
+		t.Errorf("%%v: got %q, want %q", got, "EOF")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), RetryPolicy{}, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return WithRetryable(io.EOF, true)
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Retry(): got %v after %d calls, want nil after 3", err, calls)
+	}
+}
+
+func TestRetryFailure(t *testing.T) {
+	attempt := 0
+	err := Retry(context.Background(), RetryPolicy{Attempts: 5}, func(context.Context) error {
+		attempt++
+		if attempt == 3 {
+			return WithCode(Errorf("attempt %d", attempt), CodeNotFound)
+		}
+		return WithRetryable(Errorf("attempt %d", attempt), true)
+	})
+
+	if got, want := err.Error(), "after 3 attempts: attempt 3"; got != want {
+		t.Errorf("Error(): got %q, want %q", got, want)
+	}
+	if got := Code(err); got != CodeNotFound {
+		t.Errorf("Code(): got %v, want %v", got, CodeNotFound)
+	}
+	var msgs []string
+	for _, e := range Attempts(err) {
+		msgs = append(msgs, e.Error())
+	}
+	if want := []string{"attempt 1", "attempt 2", "attempt 3"}; !reflect.DeepEqual(msgs, want) {
+		t.Errorf("Attempts(): got %q, want %q", msgs, want)
+	}
+
+	want := "^attempt 3\n" +
+		"(?s:.*)" +
+		"after 3 attempts\n" +
+		"earlier attempts:\n" +
+		"\t\\* attempt 1\n" +
+		"(?s:.*)" +
+		"\t\\* attempt 2\n" +
+		"(?s:.*)" +
+		"github.com/pkg/errors.TestRetryFailure\n"
+	if got := fmt.Sprintf("%+v", err); !regexp.MustCompile(want).MatchString(got) {
+		t.Errorf("%%+v:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	var delays []time.Duration
+	last := time.Now()
+	err := Retry(context.Background(), RetryPolicy{Attempts: 3, Delay: time.Millisecond}, func(context.Context) error {
+		now := time.Now()
+		delays = append(delays, now.Sub(last))
+		last = now
+		if len(delays) == 1 {
+			return WithRetryAfter(io.EOF, 20*time.Millisecond)
+		}
+		return WithRetryable(io.EOF, true)
+	})
+	if len(Attempts(err)) != 3 {
+		t.Fatalf("Attempts(): got %v, want 3 errors", Attempts(err))
+	}
+	if delays[1] < 20*time.Millisecond {
+		t.Errorf("second attempt: got a delay of %v, want the 20ms of RetryAfter", delays[1])
+	}
+	if delays[2] < 2*time.Millisecond {
+		t.Errorf("third attempt: got a delay of %v, want twice the 1ms delay", delays[2])
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Retry(ctx, RetryPolicy{Attempts: 10, Delay: time.Hour}, func(context.Context) error {
+		calls++
+		cancel()
+		return WithRetryable(io.EOF, true)
+	})
+	if calls != 1 || Cause(err) != io.EOF {
+		t.Errorf("Retry(): got %v after %d calls, want EOF after 1", err, calls)
+	}
+	if !Is(err, context.Canceled) {
+		t.Errorf("Is(err, context.Canceled): got false, want true")
+	}
+	if IsRetryable(err) {
+		t.Errorf("IsRetryable(err): got true, want false")
+	}
+	if got, want := err.Error(), "context canceled after 1 attempt: EOF"; got != want {
+		t.Errorf("Error(): got %q, want %q", got, want)
+	}
+}
+
+// timeoutError is an error which does not time out, whatever its cause.
+type timeoutError struct{ error }
+
+func (e *timeoutError) Timeout() bool { return false }
+func (e *timeoutError) Unwrap() error { return e.error }
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		delay, max, want time.Duration
+	}{
+		{time.Second, 0, 2 * time.Second},
+		{time.Second, 3 * time.Second, 2 * time.Second},
+		{2 * time.Second, 3 * time.Second, 3 * time.Second},
+		{math.MaxInt64/2 + 1, 0, math.MaxInt64},
+		{math.MaxInt64, 0, math.MaxInt64},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.delay, tt.max); got != tt.want {
+			t.Errorf("backoff(%v, %v): got %v, want %v", tt.delay, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestRetryContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	err := Retry(ctx, RetryPolicy{}, func(context.Context) error {
+		calls++
+		return nil
+	})
+	if calls != 0 {
+		t.Errorf("Retry(): called fn %d times, want 0", calls)
+	}
+	if !Is(err, context.Canceled) {
+		t.Errorf("Is(err, context.Canceled): got false, want true")
+	}
+	if IsRetryable(err) {
+		t.Errorf("IsRetryable(err): got true, want false")
+	}
+	if errs := Attempts(err); errs != nil {
+		t.Errorf("Attempts(err): got %v, want none", errs)
+	}
+}
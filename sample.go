@@ -0,0 +1,124 @@
+package errors
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Sampler decides which errors have their stack recorded, so that
+// programs which cannot afford to record every stack still get full traces
+// for a representative subset of their errors.
+//
+// Errors whose stack was sampled out print "stack not captured (sampled
+// out)" in place of their frames under %+v.
+type Sampler interface {
+	// Sample reports whether to record the stack of an error created at
+	// the call site identified by the program counter pc.
+	Sample(pc uintptr) bool
+}
+
+// samplerValue wraps the current Sampler, as atomic.Value cannot hold nil.
+type samplerValue struct {
+	Sampler
+}
+
+var (
+	samplerMu    sync.Mutex // serialises SetStackSampler
+	stackSampler atomic.Value
+)
+
+// SetStackSampler sets the Sampler consulted by New, Errorf, Wrap, Wrapf and
+// WithStack before they record a stack and returns the previous one. A nil
+// Sampler, the default, records every stack.
+func SetStackSampler(s Sampler) Sampler {
+	samplerMu.Lock()
+	defer samplerMu.Unlock()
+	prev, _ := stackSampler.Load().(samplerValue)
+	stackSampler.Store(samplerValue{s})
+	return prev.Sampler
+}
+
+// sample reports whether the current Sampler records the stack of an error
+// created at the call site skip frames up, as counted by runtime.Callers.
+func sample(skip int) bool {
+	s, _ := stackSampler.Load().(samplerValue)
+	if s.Sampler == nil {
+		return true
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(skip, pcs[:]) == 0 {
+		return true
+	}
+	return s.Sample(pcs[0])
+}
+
+// SampleOneIn returns a Sampler which records the stack of one error in
+// every n, starting with the first. If n is less than 2, every stack is
+// recorded.
+func SampleOneIn(n int) Sampler {
+	return &oneIn{n: uint64(n)}
+}
+
+type oneIn struct {
+	n     uint64
+	count uint64
+}
+
+func (s *oneIn) Sample(pc uintptr) bool {
+	if s.n < 2 {
+		return true
+	}
+	return (atomic.AddUint64(&s.count, 1)-1)%s.n == 0
+}
+
+// SamplePerSite returns a Sampler which records the stacks of at most n errors
+// per call site in every interval, so that call sites which fail rarely keep
+// their traces however often other call sites fail. If interval is zero or
+// less, the counts are never reset, and at most n stacks are recorded per call
+// site in all.
+func SamplePerSite(n int, interval time.Duration) Sampler {
+	return &perSite{
+		n:        n,
+		interval: interval,
+		now:      time.Now,
+		sites:    make(map[uintptr]*siteWindow),
+	}
+}
+
+type perSite struct {
+	n        int
+	interval time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	sites map[uintptr]*siteWindow
+}
+
+// siteWindow counts the stacks recorded at a call site since start.
+type siteWindow struct {
+	start time.Time
+	count int
+}
+
+func (s *perSite) Sample(pc uintptr) bool {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.sites[pc]
+	if !ok {
+		w = &siteWindow{start: now}
+		s.sites[pc] = w
+	}
+	if s.interval > 0 && now.Sub(w.start) >= s.interval {
+		w.start = now
+		w.count = 0
+	}
+	if w.count >= s.n {
+		return false
+	}
+	w.count++
+	return true
+}
@@ -0,0 +1,125 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sampleFunc func(pc uintptr) bool
+
+func (f sampleFunc) Sample(pc uintptr) bool { return f(pc) }
+
+func TestSetStackSampler(t *testing.T) {
+	var site Frame
+	defer SetStackSampler(SetStackSampler(sampleFunc(func(pc uintptr) bool {
+		site = Frame(pc)
+		return false
+	})))
+
+	err := Wrap(io.EOF, "error")
+	if got, want := site.name(), "github.com/pkg/errors.TestSetStackSampler"; got != want {
+		t.Errorf("Sample(pc): got call site %q, want %q", got, want)
+	}
+	if st := err.(interface{ StackTrace() StackTrace }).StackTrace(); len(st) != 0 {
+		t.Errorf("StackTrace(): got %d frames, want none", len(st))
+	}
+	if got, want := fmt.Sprintf("%+v", err), "EOF\nerror\nstack not captured (sampled out)"; got != want {
+		t.Errorf("fmt.Sprintf(%%+v, err): got %q, want %q", got, want)
+	}
+	if got, want := err.Error(), "error: EOF"; got != want {
+		t.Errorf("err.Error(): got %q, want %q", got, want)
+	}
+	if Cause(err) != io.EOF {
+		t.Errorf("Cause(err): got %v, want %v", Cause(err), io.EOF)
+	}
+
+	SetStackSampler(nil)
+	err = New("error")
+	if got := fmt.Sprintf("%+v", err); strings.Contains(got, "sampled out") || !strings.Contains(got, "TestSetStackSampler") {
+		t.Errorf("fmt.Sprintf(%%+v, err): got %q, want full stack", got)
+	}
+}
+
+func TestSamplerStackNone(t *testing.T) {
+	defer SetStackMode(SetStackMode(StackNone))
+	defer SetStackSampler(SetStackSampler(sampleFunc(func(uintptr) bool {
+		t.Error("Sample called with StackNone")
+		return false
+	})))
+
+	if got, want := fmt.Sprintf("%+v", New("error")), "error"; got != want {
+		t.Errorf("fmt.Sprintf(%%+v, err): got %q, want %q", got, want)
+	}
+}
+
+func TestSampleOneIn(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "xxxxxx"},
+		{1, "xxxxxx"},
+		{2, "x.x.x."},
+		{3, "x..x.."},
+	}
+
+	for _, tt := range tests {
+		s := SampleOneIn(tt.n)
+		var got []byte
+		for i := 0; i < len(tt.want); i++ {
+			if s.Sample(0) {
+				got = append(got, 'x')
+			} else {
+				got = append(got, '.')
+			}
+		}
+		if string(got) != tt.want {
+			t.Errorf("SampleOneIn(%d): got %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSamplePerSite(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := SamplePerSite(2, time.Second).(*perSite)
+	s.now = func() time.Time { return now }
+
+	tests := []struct {
+		advance time.Duration
+		pc      uintptr
+		want    bool
+	}{
+		{0, 1, true},
+		{0, 1, true},
+		{0, 1, false},
+		{0, 2, true},
+		{500 * time.Millisecond, 1, false},
+		{500 * time.Millisecond, 1, true},
+		{0, 2, true},
+		{0, 2, true},
+		{0, 2, false},
+	}
+
+	for i, tt := range tests {
+		now = now.Add(tt.advance)
+		if got := s.Sample(tt.pc); got != tt.want {
+			t.Errorf("test %d: Sample(%d): got %t, want %t", i+1, tt.pc, got, tt.want)
+		}
+	}
+}
+
+func TestSamplePerSiteNoInterval(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := SamplePerSite(1, 0).(*perSite)
+	s.now = func() time.Time { return now }
+
+	for i, want := range []bool{true, false, false} {
+		now = now.Add(time.Hour)
+		if got := s.Sample(1); got != want {
+			t.Errorf("call %d: Sample(1): got %t, want %t", i+1, got, want)
+		}
+	}
+}
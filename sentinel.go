@@ -0,0 +1,53 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+// Sentinel returns an error with the supplied message and no stack trace,
+// for declaring package level sentinel errors:
+//
+//	var ErrNotFound = errors.Sentinel("not found")
+//
+// Unlike New, Sentinel does not record the meaningless stack of the package's
+// initialisation, and its errors print only their message, even under %+v.
+// Each call returns a distinct error, which compares equal only to itself,
+// whatever its message. Use Raise to return a sentinel with the stack of the
+// point where it occurred.
+func Sentinel(message string) error {
+	return &sentinelError{message}
+}
+
+// sentinelError is an error that has a message, but no stack or cause.
+type sentinelError struct {
+	msg string
+}
+
+func (e *sentinelError) Error() string { return e.msg }
+
+func (e *sentinelError) layerMessage() (string, bool) { return e.msg, true }
+func (e *sentinelError) toJSONLayer(l *jsonLayer)     { l.Type = "sentinel" }
+
+func (e *sentinelError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's':
+		io.WriteString(s, e.msg)
+	case 'q':
+		fmt.Fprintf(s, "%q", e.msg)
+	}
+}
+
+// Raise annotates err, typically a sentinel error, with a stack trace at the
+// point Raise was called. The returned error has the message of err, and
+// err as its Cause, so that Is reports it as matching err.
+// If err is nil, Raise returns nil.
+func Raise(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &withStack{
+		err,
+		callers(),
+	}
+}
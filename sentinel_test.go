@@ -0,0 +1,47 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+var errSentinel = Sentinel("sentinel")
+
+func TestSentinel(t *testing.T) {
+	if errSentinel == Sentinel("sentinel") {
+		t.Errorf("Sentinel(): got equal errors for equal messages, want distinct ones")
+	}
+	if _, ok := errSentinel.(stackTracer); ok {
+		t.Errorf("Sentinel(): got an error with a stack trace, want none")
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%s", "sentinel"},
+		{"%v", "sentinel"},
+		{"%+v", "sentinel"},
+		{"%q", `"sentinel"`},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprintf(tt.format, errSentinel); got != tt.want {
+			t.Errorf("fmt.Sprintf(%q, Sentinel()): got %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestRaise(t *testing.T) {
+	if got := Raise(nil); got != nil {
+		t.Errorf("Raise(nil): got %#v, expected nil", got)
+	}
+
+	err := Raise(errSentinel)
+	if Cause(err) != errSentinel {
+		t.Errorf("Cause(Raise()): got %v, want %v", Cause(err), errSentinel)
+	}
+	testFormatRegexp(t, 0, err, "%+v", "sentinel\n"+
+		"github.com/pkg/errors.TestRaise\n"+
+		"\t.+/github.com/pkg/errors/sentinel_test.go:40")
+}
@@ -0,0 +1,191 @@
+//go:build go1.21
+// +build go1.21
+
+package errors
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strconv"
+)
+
+// LogValue returns the structured form in which err is logged by log/slog: a
+// group holding
+//
+//	msg     the error's message
+//	causes  the messages of the errors in its chain of causes, outermost first
+//	fields  the fields set on the chain, as returned by AllFields
+//	code    the code of the chain, as returned by Code
+//	stack   the frames of the innermost stack trace in the chain
+//	errors  for errors which wrap several errors, the LogValue of each
+//
+// Members which would be empty are omitted. The errors returned by this
+// package implement slog.LogValuer with this function; NewSlogHandler
+// applies it to other errors too.
+func LogValue(err error) slog.Value {
+	if err == nil {
+		return slog.Value{}
+	}
+
+	attrs := []slog.Attr{slog.String("msg", errorText(err))}
+
+	var causes []string
+	var tracer stackTracer
+	var errs []error
+	for e := err; e != nil; e = unwrapOnce(e) {
+		if msg, ok := layerMessage(e); ok {
+			causes = append(causes, msg)
+		}
+		if st, ok := e.(stackTracer); ok {
+			tracer = st
+		}
+		if m, ok := e.(interface{ Unwrap() []error }); ok {
+			errs = m.Unwrap()
+		}
+	}
+	if len(causes) > 0 {
+		attrs = append(attrs, slog.Any("causes", causes))
+	}
+
+	if fields := AllFields(err); len(fields) > 0 {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		group := make([]slog.Attr, len(keys))
+		for i, k := range keys {
+			group[i] = slog.Any(k, scrubValue(fields[k]))
+		}
+		attrs = append(attrs, slog.Attr{Key: "fields", Value: slog.GroupValue(group...)})
+	}
+
+	if code := Code(err); code != CodeUnknown {
+		attrs = append(attrs, slog.String("code", code.String()))
+	}
+
+	if tracer != nil {
+		st := tracer.StackTrace()
+		frames := make([]FrameInfo, len(st))
+		for i, f := range st {
+			frames[i] = f.Info()
+		}
+		if len(frames) > 0 {
+			attrs = append(attrs, slog.Any("stack", frames))
+		}
+	}
+
+	if len(errs) > 0 {
+		group := make([]slog.Attr, len(errs))
+		for i, e := range errs {
+			group[i] = slog.Attr{Key: strconv.Itoa(i), Value: LogValue(e)}
+		}
+		attrs = append(attrs, slog.Attr{Key: "errors", Value: slog.GroupValue(group...)})
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements slog.LogValuer.
+func (f *fundamental) LogValue() slog.Value { return LogValue(f) }
+
+// LogValue implements slog.LogValuer.
+func (e *sentinelError) LogValue() slog.Value { return LogValue(e) }
+
+// LogValue implements slog.LogValuer.
+func (e *definedError) LogValue() slog.Value { return LogValue(e) }
+
+// LogValue implements slog.LogValuer.
+func (w *withStack) LogValue() slog.Value { return LogValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (w *withMessage) LogValue() slog.Value { return LogValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (w *withFields) LogValue() slog.Value { return LogValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (w *withCode) LogValue() slog.Value { return LogValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (w *withSpawn) LogValue() slog.Value { return LogValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (w *withRetry) LogValue() slog.Value { return LogValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (w *withAttempts) LogValue() slog.Value { return LogValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (w *withPublic) LogValue() slog.Value { return LogValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (m *multiError) LogValue() slog.Value { return LogValue(m) }
+
+// LogValue implements slog.LogValuer.
+func (r *remoteError) LogValue() slog.Value { return LogValue(r) }
+
+// LogValue implements slog.LogValuer.
+func (w *remoteWrapper) LogValue() slog.Value { return LogValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (m *remoteJoin) LogValue() slog.Value { return LogValue(m) }
+
+// LogValue implements slog.LogValuer, logging the value as Redacted.
+func (s Sensitive) LogValue() slog.Value { return slog.StringValue(Redacted) }
+
+// NewSlogHandler returns a slog.Handler which passes records on to h with the
+// value of every attribute holding an error, whether from this package or
+// not, replaced by its LogValue.
+func NewSlogHandler(h slog.Handler) slog.Handler {
+	return &slogHandler{h}
+}
+
+type slogHandler struct {
+	h slog.Handler
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	expanded := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		expanded.AddAttrs(expandError(a))
+		return true
+	})
+	return h.h.Handle(ctx, expanded)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	expanded := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		expanded[i] = expandError(a)
+	}
+	return &slogHandler{h.h.WithAttrs(expanded)}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	return &slogHandler{h.h.WithGroup(name)}
+}
+
+// expandError replaces the value of a, or of the members of a if it is a
+// group, by its LogValue if it holds an error.
+func expandError(a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = LogValue(err)
+		}
+	case slog.KindGroup:
+		group := a.Value.Group()
+		expanded := make([]slog.Attr, len(group))
+		for i, m := range group {
+			expanded[i] = expandError(m)
+		}
+		a.Value = slog.GroupValue(expanded...)
+	}
+	return a
+}
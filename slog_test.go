@@ -0,0 +1,148 @@
+//go:build go1.21
+// +build go1.21
+
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	err := WithValue(Wrap(WithFields(New("error"), Fields{"a": 1}), "wrapped"), "b", "x")
+
+	got := err.(slog.LogValuer).LogValue()
+	if got.Kind() != slog.KindGroup {
+		t.Fatalf("LogValue(): got kind %v, want %v", got.Kind(), slog.KindGroup)
+	}
+	attrs := map[string]slog.Value{}
+	for _, a := range got.Group() {
+		attrs[a.Key] = a.Value
+	}
+
+	if got := attrs["msg"].String(); got != "wrapped: error" {
+		t.Errorf("msg: got %q, want %q", got, "wrapped: error")
+	}
+	if got, want := attrs["causes"].Any(), []string{"wrapped", "error"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("causes: got %v, want %v", got, want)
+	}
+	var fields []string
+	for _, a := range attrs["fields"].Group() {
+		fields = append(fields, a.String())
+	}
+	if want := []string{"a=1", "b=x"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields: got %v, want %v", fields, want)
+	}
+	frames, ok := attrs["stack"].Any().([]FrameInfo)
+	if !ok || len(frames) == 0 {
+		t.Fatalf("stack: got %v, want frames", attrs["stack"])
+	}
+	if frames[0].Function != "github.com/pkg/errors.TestLogValue" || frames[0].Line != 16 {
+		t.Errorf("stack[0]: got %+v, want the frame of New", frames[0])
+	}
+	if _, ok := attrs["errors"]; ok {
+		t.Errorf("errors: got %v, want none", attrs["errors"])
+	}
+}
+
+func TestLogValueJoin(t *testing.T) {
+	got := LogValue(Join(io.EOF, New("error")))
+
+	for _, a := range got.Group() {
+		if a.Key != "errors" {
+			continue
+		}
+		errs := a.Value.Group()
+		if len(errs) != 2 || errs[0].Key != "0" || errs[1].Key != "1" {
+			t.Fatalf("errors: got %v, want two errors", errs)
+		}
+		if msg := errs[0].Value.Group()[0]; msg.String() != "msg=EOF" {
+			t.Errorf("errors[0]: got %v, want msg=EOF", msg)
+		}
+		return
+	}
+	t.Errorf("LogValue(Join()): got %v, want errors", got)
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewSlogHandler(slog.NewJSONHandler(&buf, nil)))
+
+	logger.With("first", io.EOF).WithGroup("g").Error("failed",
+		"err", WithStack(io.ErrUnexpectedEOF),
+		slog.Group("inner", "cause", io.EOF),
+	)
+
+	var got struct {
+		First struct {
+			Msg string `json:"msg"`
+		} `json:"first"`
+		G struct {
+			Err struct {
+				Msg    string      `json:"msg"`
+				Causes []string    `json:"causes"`
+				Stack  []FrameInfo `json:"stack"`
+			} `json:"err"`
+			Inner struct {
+				Cause struct {
+					Msg string `json:"msg"`
+				} `json:"cause"`
+			} `json:"inner"`
+		} `json:"g"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal(%s): %v", buf.Bytes(), err)
+	}
+
+	if got.First.Msg != "EOF" {
+		t.Errorf("first.msg: got %q, want %q in %s", got.First.Msg, "EOF", buf.Bytes())
+	}
+	if got.G.Err.Msg != "unexpected EOF" || len(got.G.Err.Causes) != 1 {
+		t.Errorf("g.err: got %+v, want unexpected EOF in %s", got.G.Err, buf.Bytes())
+	}
+	if len(got.G.Err.Stack) == 0 || got.G.Err.Stack[0].Function != "github.com/pkg/errors.TestSlogHandler" {
+		t.Errorf("g.err.stack: got %+v, want frames in %s", got.G.Err.Stack, buf.Bytes())
+	}
+	if got.G.Inner.Cause.Msg != "EOF" {
+		t.Errorf("g.inner.cause.msg: got %q, want %q in %s", got.G.Inner.Cause.Msg, "EOF", buf.Bytes())
+	}
+}
+
+// replaceScrubber replaces every old in the strings it scrubs with new.
+type replaceScrubber struct{ old, new string }
+
+func (r replaceScrubber) Scrub(s string) string {
+	return string(bytes.ReplaceAll([]byte(s), []byte(r.old), []byte(r.new)))
+}
+
+func TestLogValueScrubbers(t *testing.T) {
+	defer SetScrubbers(SetScrubbers(replaceScrubber{"carol@example.com", "<email>"})...)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Error("login failed", "err", WithFields(io.EOF, Fields{"from": "carol@example.com", "n": 1}))
+
+	if got := buf.Bytes(); bytes.Contains(got, []byte("carol@")) || !bytes.Contains(got, []byte("err.fields.from=<email> err.fields.n=1")) {
+		t.Errorf("log: got %q, want the field scrubbed", got)
+	}
+}
+
+func TestLogValueScrubbersForeignCause(t *testing.T) {
+	defer SetScrubbers(SetScrubbers(replaceScrubber{"hunter2", "***"})...)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Error("query failed", "err", Wrap(&foreignError{"db: password=hunter2"}, "query"))
+
+	if got := buf.Bytes(); bytes.Contains(got, []byte("hunter2")) {
+		t.Errorf("log: got %q, want the password scrubbed", got)
+	}
+}
+
+type foreignError struct{ msg string }
+
+func (e *foreignError) Error() string { return e.msg }
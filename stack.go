@@ -3,51 +3,143 @@ package errors
 import (
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
+// DefaultStackDepth is the maximum number of frames recorded by New, Errorf,
+// Wrap, Wrapf and WithStack unless changed with SetStackDepth.
+const DefaultStackDepth = 32
+
+var stackDepth int32 = DefaultStackDepth
+
+// StackDepth returns the maximum number of frames currently recorded by New,
+// Errorf, Wrap, Wrapf and WithStack. A depth of zero means that stacks are
+// recorded in full.
+func StackDepth() int { return int(atomic.LoadInt32(&stackDepth)) }
+
+// SetStackDepth sets the maximum number of frames recorded by New, Errorf,
+// Wrap, Wrapf and WithStack and returns the previous depth. A depth of zero
+// or less records every frame of the stack, however deep.
+//
+// Stacks deeper than depth are truncated; the number of frames dropped is
+// reported under %+v as "... N more frames".
+func SetStackDepth(depth int) int {
+	if depth < 0 {
+		depth = 0
+	}
+	return int(atomic.SwapInt32(&stackDepth, int32(depth)))
+}
+
+// StackMode selects how much of the stack New, Errorf, Wrap, Wrapf and
+// WithStack record.
+type StackMode int32
+
+const (
+	// StackFull records up to StackDepth frames. This is the default.
+	StackFull StackMode = iota
+
+	// StackCaller records only the frame of the function which called
+	// New, Errorf, Wrap, Wrapf or WithStack.
+	StackCaller
+
+	// StackNone records no stack. The errors returned keep their messages
+	// and causes, but their StackTrace is empty and %+v prints no frames.
+	StackNone
+)
+
+// StackModeEnv is the environment variable from which the initial StackMode
+// is read when the program starts: "full", "caller" or "none".
+const StackModeEnv = "PKG_ERRORS_STACK"
+
+var stackMode = int32(StackFull)
+
+func init() {
+	if mode, ok := parseStackMode(os.Getenv(StackModeEnv)); ok {
+		stackMode = int32(mode)
+	}
+}
+
+// parseStackMode parses the value of StackModeEnv.
+func parseStackMode(s string) (StackMode, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "full":
+		return StackFull, true
+	case "caller":
+		return StackCaller, true
+	case "none", "off":
+		return StackNone, true
+	}
+	return StackFull, false
+}
+
+func (m StackMode) String() string {
+	switch m {
+	case StackFull:
+		return "full"
+	case StackCaller:
+		return "caller"
+	case StackNone:
+		return "none"
+	}
+	return "StackMode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// CurrentStackMode returns the StackMode used by New, Errorf, Wrap, Wrapf and
+// WithStack.
+func CurrentStackMode() StackMode { return StackMode(atomic.LoadInt32(&stackMode)) }
+
+// SetStackMode sets the StackMode used by New, Errorf, Wrap, Wrapf and
+// WithStack and returns the previous mode. It overrides StackModeEnv.
+func SetStackMode(mode StackMode) StackMode {
+	return StackMode(atomic.SwapInt32(&stackMode, int32(mode)))
+}
+
 // Frame represents a program counter inside a stack frame.
 // For historical reasons if Frame is interpreted as a uintptr
 // its value represents the program counter + 1.
 type Frame uintptr
 
-// pc returns the program counter for this frame;
-// multiple frames may have the same PC value.
-func (f Frame) pc() uintptr { return uintptr(f) - 1 }
+// FrameInfo is the symbolic information of a Frame, as returned by
+// Frame.Info.
+type FrameInfo struct {
+	// Function is the fully qualified name of the function, as printed
+	// by %+s, e.g. "github.com/pkg/errors.(*X).ptr".
+	Function string `json:"function"`
+
+	// Name is the name of the function without its package path, as
+	// printed by %n, e.g. "(*X).ptr".
+	Name string `json:"name"`
+
+	// Package is the import path of the function's package,
+	// e.g. "github.com/pkg/errors".
+	Package string `json:"package"`
+
+	// File is the full path to the source file of the function.
+	File string `json:"file"`
+
+	// Line is the line number in File.
+	Line int `json:"line"`
+}
+
+// Info returns the function, package, file and line this Frame resolves to.
+// If the Frame's pc is not known, Function, Name and File are "unknown".
+func (f Frame) Info() FrameInfo { return frameCache.lookup(uintptr(f)) }
 
 // file returns the full path to the file that contains the
 // function for this Frame's pc.
-func (f Frame) file() string {
-	fn := runtime.FuncForPC(f.pc())
-	if fn == nil {
-		return "unknown"
-	}
-	file, _ := fn.FileLine(f.pc())
-	return file
-}
+func (f Frame) file() string { return f.Info().File }
 
 // line returns the line number of source code of the
 // function for this Frame's pc.
-func (f Frame) line() int {
-	fn := runtime.FuncForPC(f.pc())
-	if fn == nil {
-		return 0
-	}
-	_, line := fn.FileLine(f.pc())
-	return line
-}
+func (f Frame) line() int { return f.Info().Line }
 
 // name returns the name of this function, if known.
-func (f Frame) name() string {
-	fn := runtime.FuncForPC(f.pc())
-	if fn == nil {
-		return "unknown"
-	}
-	return fn.Name()
-}
+func (f Frame) name() string { return f.Info().Function }
 
 // Format formats the frame according to the fmt.Formatter interface.
 //
@@ -62,35 +154,41 @@ func (f Frame) name() string {
 //          GOPATH separated by \n\t (<funcname>\n\t<path>)
 //    %+v   equivalent to %+s:%d
 func (f Frame) Format(s fmt.State, verb rune) {
+	info := f.Info()
 	switch verb {
 	case 's':
-		switch {
-		case s.Flag('+'):
-			io.WriteString(s, f.name())
-			io.WriteString(s, "\n\t")
-			io.WriteString(s, f.file())
-		default:
-			io.WriteString(s, path.Base(f.file()))
-		}
+		info.formatFile(s)
 	case 'd':
-		io.WriteString(s, strconv.Itoa(f.line()))
+		io.WriteString(s, strconv.Itoa(info.Line))
 	case 'n':
-		io.WriteString(s, funcname(f.name()))
+		io.WriteString(s, info.Name)
 	case 'v':
-		f.Format(s, 's')
+		info.formatFile(s)
 		io.WriteString(s, ":")
-		f.Format(s, 'd')
+		io.WriteString(s, strconv.Itoa(info.Line))
+	}
+}
+
+// formatFile writes the source file as Frame.Format does for %s and %+s.
+func (info FrameInfo) formatFile(s fmt.State) {
+	switch {
+	case s.Flag('+'):
+		io.WriteString(s, info.Function)
+		io.WriteString(s, "\n\t")
+		io.WriteString(s, info.File)
+	default:
+		io.WriteString(s, path.Base(info.File))
 	}
 }
 
 // MarshalText formats a stacktrace Frame as a text string. The output is the
 // same as that of fmt.Sprintf("%+v", f), but without newlines or tabs.
 func (f Frame) MarshalText() ([]byte, error) {
-	name := f.name()
-	if name == "unknown" {
-		return []byte(name), nil
+	info := f.Info()
+	if info.Function == "unknown" {
+		return []byte(info.Function), nil
 	}
-	return []byte(fmt.Sprintf("%s %s:%d", name, f.file(), f.line())), nil
+	return []byte(fmt.Sprintf("%s %s:%d", info.Function, info.File, info.Line)), nil
 }
 
 // StackTrace is stack of Frames from innermost (newest) to outermost (oldest).
@@ -137,35 +235,161 @@ func (st StackTrace) formatSlice(s fmt.State, verb rune) {
 }
 
 // stack represents a stack of program counters.
-type stack []uintptr
+type stack struct {
+	pcs []uintptr
+
+	// more is the number of frames beyond pcs which were not
+	// recorded because the stack was deeper than the capture depth.
+	more int
+
+	// sampledOut is set if the stack was not recorded because the
+	// current Sampler skipped it.
+	sampledOut bool
+}
 
 func (s *stack) Format(st fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		switch {
 		case st.Flag('+'):
-			for _, pc := range *s {
-				f := Frame(pc)
-				fmt.Fprintf(st, "\n%+v", f)
+			for _, f := range s.StackTrace() {
+				io.WriteString(st, "\n")
+				f.Format(st, verb)
+			}
+			if s.more > 0 {
+				fmt.Fprintf(st, "\n... %d more frames", s.more)
+			}
+			if s.sampledOut {
+				io.WriteString(st, "\nstack not captured (sampled out)")
 			}
 		}
 	}
 }
 
+// formatCommon formats the stack as Format does for %+v, except that the
+// outermost frames it shares with inner, the stack of the error it wraps, are
+// summarised as "... N frames in common".
+func (s *stack) formatCommon(st fmt.State, inner StackTrace) {
+	frames := s.StackTrace()
+	common := 0
+	if s.more == 0 {
+		for common < len(frames) && common < len(inner) &&
+			frames[len(frames)-1-common] == inner[len(inner)-1-common] {
+			common++
+		}
+	}
+	for _, f := range frames[:len(frames)-common] {
+		io.WriteString(st, "\n")
+		f.Format(st, 'v')
+	}
+	if common > 0 {
+		fmt.Fprintf(st, "\n... %d frames in common", common)
+	}
+	if s.more > 0 {
+		fmt.Fprintf(st, "\n... %d more frames", s.more)
+	}
+	if s.sampledOut {
+		io.WriteString(st, "\nstack not captured (sampled out)")
+	}
+}
+
+// frameInfos returns the FrameInfo of each frame of the stack, and the number
+// of frames which were not recorded.
+func (s *stack) frameInfos() ([]FrameInfo, int) {
+	st := s.StackTrace()
+	infos := make([]FrameInfo, len(st))
+	for i, f := range st {
+		infos[i] = f.Info()
+	}
+	return infos, s.more
+}
+
+// framer is implemented by the errors of this package which record a stack
+// trace, to report its frames as frameInfos does.
+type framer interface {
+	frameInfos() ([]FrameInfo, int)
+}
+
+// StackTrace resolves the recorded program counters into a StackTrace with
+// one Frame per logical call, expanding calls which were inlined into their
+// callers the way the runtime does when printing a panic.
 func (s *stack) StackTrace() StackTrace {
-	f := make([]Frame, len(*s))
-	for i := 0; i < len(f); i++ {
-		f[i] = Frame((*s)[i])
+	f := make([]Frame, 0, len(s.pcs))
+	if len(s.pcs) == 0 {
+		return f
+	}
+	frames := runtime.CallersFrames(s.pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.PC != 0 {
+			// Frame holds the return address, as runtime.Callers
+			// does, so that it resolves back to this same frame.
+			f = append(f, Frame(frame.PC+1))
+		}
+		if !more {
+			return f
+		}
 	}
-	return f
 }
 
 func callers() *stack {
-	const depth = 32
-	var pcs [depth]uintptr
-	n := runtime.Callers(3, pcs[:])
-	var st stack = pcs[0:n]
-	return &st
+	mode := CurrentStackMode()
+	if mode != StackNone && !sample(4) {
+		return &stack{sampledOut: true}
+	}
+	return capture(4, mode, StackDepth())
+}
+
+// capture records the calling goroutine's stack as mode requires, skipping
+// the first skip frames as runtime.Callers does.
+func capture(skip int, mode StackMode, depth int) *stack {
+	switch mode {
+	case StackNone:
+		return &stack{}
+	case StackCaller:
+		pcs := make([]uintptr, 1)
+		n := runtime.Callers(skip, pcs)
+		return &stack{pcs: pcs[0:n]}
+	}
+	return callersDepth(skip+1, depth)
+}
+
+// callersDepth records at most depth frames of the calling goroutine's
+// stack, skipping the first skip frames as runtime.Callers does. A depth of
+// zero or less records the whole stack.
+func callersDepth(skip, depth int) *stack {
+	size := depth
+	if size <= 0 {
+		size = DefaultStackDepth
+	}
+	pcs := make([]uintptr, size)
+	n := runtime.Callers(skip, pcs)
+	if n < len(pcs) {
+		return &stack{pcs: pcs[0:n]}
+	}
+
+	// The buffer was filled, so the stack may be deeper than it. Grow
+	// the buffer until the whole stack fits, either to keep every frame
+	// or to count the frames we are about to drop.
+	all := pcs
+	for n == len(all) {
+		all = make([]uintptr, 2*len(all))
+		n = runtime.Callers(skip, all)
+	}
+	if depth <= 0 {
+		return &stack{pcs: all[0:n]}
+	}
+	return &stack{pcs: pcs, more: n - len(pcs)}
+}
+
+// pkgname returns the package path component of a function's name reported by func.Name().
+func pkgname(name string) string {
+	i := strings.LastIndex(name, "/")
+	j := strings.Index(name[i+1:], ".")
+	if j < 0 {
+		return ""
+	}
+	return name[:i+1+j]
 }
 
 // funcname removes the path prefix component of a function's name reported by func.Name().
@@ -0,0 +1,72 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func recurse(depth int, fn func() error) error {
+	if depth == 0 {
+		return fn()
+	}
+	return recurse(depth-1, fn)
+}
+
+func TestSetStackDepth(t *testing.T) {
+	defer SetStackDepth(SetStackDepth(8))
+
+	if got := StackDepth(); got != 8 {
+		t.Fatalf("StackDepth(): got %d, want %d", got, 8)
+	}
+
+	err := recurse(20, func() error { return New("deep") })
+	st := err.(interface{ StackTrace() StackTrace }).StackTrace()
+	if len(st) != 8 {
+		t.Errorf("len(StackTrace()): got %d, want %d", len(st), 8)
+	}
+	testFormatRegexp(t, 0, st[0], "%n", `TestSetStackDepth.func1`)
+	got := fmt.Sprintf("%+v", err)
+	if !regexp.MustCompile(`\n\.\.\. \d+ more frames$`).MatchString(got) {
+		t.Errorf("fmt.Sprintf(%%+v, err): missing truncation marker:\n%s", got)
+	}
+
+	SetStackDepth(-1)
+	if got := StackDepth(); got != 0 {
+		t.Fatalf("StackDepth(): got %d, want %d", got, 0)
+	}
+	err = recurse(2*DefaultStackDepth, func() error { return New("deep") })
+	st = err.(interface{ StackTrace() StackTrace }).StackTrace()
+	if len(st) <= 2*DefaultStackDepth {
+		t.Errorf("len(StackTrace()): got %d, want more than %d", len(st), 2*DefaultStackDepth)
+	}
+	if got := fmt.Sprintf("%+v", err); strings.Contains(got, "more frames") {
+		t.Errorf("fmt.Sprintf(%%+v, err): unexpected truncation marker:\n%s", got)
+	}
+}
+
+func TestWithStackDepth(t *testing.T) {
+	tests := []struct {
+		depth int
+		want  int
+	}{
+		{1, 1},
+		{4, 4},
+		{0, 2*DefaultStackDepth + 1},
+	}
+
+	for i, tt := range tests {
+		err := recurse(2*DefaultStackDepth, func() error { return WithStackDepth(io.EOF, tt.depth) })
+		st := err.(interface{ StackTrace() StackTrace }).StackTrace()
+		if len(st) < tt.want || tt.depth > 0 && len(st) != tt.want {
+			t.Errorf("test %d: WithStackDepth(io.EOF, %d): got %d frames, want %d", i+1, tt.depth, len(st), tt.want)
+		}
+		testFormatRegexp(t, i, st[0], "%n", `TestWithStackDepth.func1`)
+	}
+
+	if got := WithStackDepth(nil, 1); got != nil {
+		t.Errorf("WithStackDepth(nil, 1): got %#v, expected nil", got)
+	}
+}
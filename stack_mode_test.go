@@ -0,0 +1,127 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestSetStackMode(t *testing.T) {
+	defer SetStackMode(SetStackMode(StackFull))
+
+	tests := []struct {
+		mode   StackMode
+		frames int
+	}{
+		{StackFull, 3},
+		{StackCaller, 1},
+		{StackNone, 0},
+	}
+
+	for i, tt := range tests {
+		SetStackMode(tt.mode)
+		if got := CurrentStackMode(); got != tt.mode {
+			t.Fatalf("test %d: CurrentStackMode(): got %v, want %v", i+1, got, tt.mode)
+		}
+
+		for _, err := range []error{
+			New("error"),
+			Errorf("error%d", 1),
+			Wrap(io.EOF, "error"),
+			Wrapf(io.EOF, "error%d", 1),
+			WithStack(io.EOF),
+		} {
+			st := err.(interface{ StackTrace() StackTrace }).StackTrace()
+			if len(st) < tt.frames || tt.mode != StackFull && len(st) != tt.frames {
+				t.Errorf("test %d: %v: got %d frames, want %d", i+1, tt.mode, len(st), tt.frames)
+			}
+			if tt.frames > 0 {
+				testFormatRegexp(t, i, st[0], "%n", "TestSetStackMode")
+			}
+		}
+	}
+}
+
+func TestStackNone(t *testing.T) {
+	defer SetStackMode(SetStackMode(StackNone))
+
+	err := Wrap(New("error"), "wrapped")
+	if got, want := fmt.Sprintf("%+v", err), "error\nwrapped"; got != want {
+		t.Errorf("fmt.Sprintf(%%+v, err): got %q, want %q", got, want)
+	}
+	if got, want := err.Error(), "wrapped: error"; got != want {
+		t.Errorf("err.Error(): got %q, want %q", got, want)
+	}
+	if got := Cause(err); got.Error() != "error" {
+		t.Errorf("Cause(err): got %v, want %v", got, "error")
+	}
+}
+
+func TestWithStackMode(t *testing.T) {
+	tests := []struct {
+		mode   StackMode
+		frames int
+	}{
+		{StackFull, 3},
+		{StackCaller, 1},
+		{StackNone, 0},
+	}
+
+	for i, tt := range tests {
+		err := WithStackMode(io.EOF, tt.mode)
+		if Cause(err) != io.EOF {
+			t.Errorf("test %d: Cause(WithStackMode(io.EOF, %v)): got %v, want %v", i+1, tt.mode, Cause(err), io.EOF)
+		}
+		st := err.(interface{ StackTrace() StackTrace }).StackTrace()
+		if len(st) < tt.frames || tt.mode != StackFull && len(st) != tt.frames {
+			t.Errorf("test %d: WithStackMode(io.EOF, %v): got %d frames, want %d", i+1, tt.mode, len(st), tt.frames)
+		}
+		if tt.frames > 0 {
+			testFormatRegexp(t, i, st[0], "%n", "TestWithStackMode")
+		}
+	}
+
+	if got := WithStackMode(nil, StackFull); got != nil {
+		t.Errorf("WithStackMode(nil, StackFull): got %#v, expected nil", got)
+	}
+}
+
+func TestParseStackMode(t *testing.T) {
+	tests := []struct {
+		s    string
+		mode StackMode
+		ok   bool
+	}{
+		{"", StackFull, false},
+		{"full", StackFull, true},
+		{"Caller", StackCaller, true},
+		{" none ", StackNone, true},
+		{"off", StackNone, true},
+		{"sometimes", StackFull, false},
+	}
+
+	for _, tt := range tests {
+		mode, ok := parseStackMode(tt.s)
+		if mode != tt.mode || ok != tt.ok {
+			t.Errorf("parseStackMode(%q): got %v, %t, want %v, %t", tt.s, mode, ok, tt.mode, tt.ok)
+		}
+	}
+}
+
+func TestStackModeString(t *testing.T) {
+	tests := []struct {
+		mode StackMode
+		want string
+	}{
+		{StackFull, "full"},
+		{StackCaller, "caller"},
+		{StackNone, "none"},
+		{StackMode(42), "StackMode(42)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.mode.String(); got != tt.want {
+			t.Errorf("StackMode(%d).String(): got %q, want %q", int(tt.mode), got, tt.want)
+		}
+	}
+}
@@ -172,7 +172,7 @@ func stackTrace() StackTrace {
 	const depth = 8
 	var pcs [depth]uintptr
 	n := runtime.Callers(1, pcs[:])
-	var st stack = pcs[0:n]
+	st := stack{pcs: pcs[0:n]}
 	return st.StackTrace()
 }
 
@@ -248,3 +248,94 @@ func caller() Frame {
 	frame, _ := frames.Next()
 	return Frame(frame.PC)
 }
+
+//go:noinline
+func inlinedCaller() error { return inlinedCallee() }
+
+func inlinedCallee() error { return New("inlined") } // inlined into inlinedCaller
+
+func TestStackTraceInlined(t *testing.T) {
+	st := inlinedCaller().(interface{ StackTrace() StackTrace }).StackTrace()
+
+	var pcs [8]uintptr
+	n := runtime.Callers(1, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	want, _ := frames.Next()
+
+	for i, name := range []string{
+		"github.com/pkg/errors.inlinedCallee",
+		"github.com/pkg/errors.inlinedCaller",
+		want.Function,
+	} {
+		if got := st[i].name(); got != name {
+			t.Errorf("StackTrace()[%d]: got %q, want %q", i, got, name)
+		}
+	}
+	if got := st[0].line(); got != 255 {
+		t.Errorf("StackTrace()[0].line(): got %d, want %d", got, 255)
+	}
+}
+
+func TestFrameInfo(t *testing.T) {
+	tests := []struct {
+		Frame
+		want FrameInfo
+	}{{
+		initpc,
+		FrameInfo{
+			Function: "github.com/pkg/errors.init",
+			Name:     "init",
+			Package:  "github.com/pkg/errors",
+			Line:     9,
+		},
+	}, {
+		func() Frame {
+			var x X
+			return x.ptr()
+		}(),
+		FrameInfo{
+			Function: "github.com/pkg/errors.(*X).ptr",
+			Name:     "(*X).ptr",
+			Package:  "github.com/pkg/errors",
+			Line:     20,
+		},
+	}, {
+		0,
+		FrameInfo{
+			Function: "unknown",
+			Name:     "unknown",
+			File:     "unknown",
+		},
+	}}
+
+	for i, tt := range tests {
+		got := tt.Frame.Info()
+		if tt.want.File == "" {
+			tt.want.File = tt.Frame.file()
+		}
+		if got != tt.want {
+			t.Errorf("test %d: Info():\n got %+v\nwant %+v", i+1, got, tt.want)
+		}
+	}
+}
+
+func TestPkgname(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"", ""},
+		{"runtime.main", "runtime"},
+		{"github.com/pkg/errors.funcname", "github.com/pkg/errors"},
+		{"funcname", ""},
+		{"io.copyBuffer", "io"},
+		{"main.(*R).Write", "main"},
+		{"gopkg.in/yaml%2ev2.Unmarshal", "gopkg.in/yaml%2ev2"},
+	}
+
+	for _, tt := range tests {
+		got := pkgname(tt.name)
+		if got != tt.want {
+			t.Errorf("pkgname(%q): want: %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package errors
+
+// Walk calls visit for err and for each error in its tree of causes, depth
+// first, until visit returns false. The tree is that formed by following the
+// Cause method of errors which have one, and the Unwrap methods, returning
+// error or []error, of errors which do not; errors returned by Join, for
+// instance, have each of the errors they combine as children.
+//
+// If err is nil, visit is not called.
+func Walk(err error, visit func(error) bool) {
+	walk(err, visit)
+}
+
+// walk is Walk, reporting whether visit returned false.
+func walk(err error, visit func(error) bool) bool {
+	type multiWrapper interface {
+		Unwrap() []error
+	}
+
+	for err != nil {
+		if !visit(err) {
+			return false
+		}
+		if m, ok := err.(multiWrapper); ok {
+			for _, err := range m.Unwrap() {
+				if !walk(err, visit) {
+					return false
+				}
+			}
+			return true
+		}
+		err = unwrapOnce(err)
+	}
+	return true
+}
+
+// Find returns the first error in err's tree of causes, in the order in which
+// Walk visits them, for which match returns true, or nil if there is none.
+func Find(err error, match func(error) bool) error {
+	var found error
+	Walk(err, func(err error) bool {
+		if match(err) {
+			found = err
+			return false
+		}
+		return true
+	})
+	return found
+}
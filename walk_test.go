@@ -0,0 +1,58 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	err := Wrap(Join(WithMessage(io.EOF, "first"), fmt.Errorf("second: %w", io.ErrUnexpectedEOF)), "outer")
+
+	var msgs []string
+	Walk(err, func(err error) bool {
+		msgs = append(msgs, err.Error())
+		return true
+	})
+	want := []string{
+		"outer: first: EOF\nsecond: unexpected EOF",
+		"outer: first: EOF\nsecond: unexpected EOF",
+		"first: EOF\nsecond: unexpected EOF",
+		"first: EOF",
+		"EOF",
+		"second: unexpected EOF",
+		"unexpected EOF",
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("Walk(): visited %q, want %q", msgs, want)
+	}
+
+	n := 0
+	Walk(err, func(err error) bool {
+		n++
+		return err != io.EOF
+	})
+	if n != 5 {
+		t.Errorf("Walk() stopping at io.EOF: visited %d errors, want 5", n)
+	}
+
+	Walk(nil, func(err error) bool {
+		t.Errorf("Walk(nil): visited %v", err)
+		return true
+	})
+}
+
+func TestFind(t *testing.T) {
+	err := Wrap(Join(New("first"), WithCode(io.ErrUnexpectedEOF, CodeNotFound)), "outer")
+
+	if got := Find(err, func(err error) bool { return err == io.ErrUnexpectedEOF }); got != io.ErrUnexpectedEOF {
+		t.Errorf("Find(io.ErrUnexpectedEOF): got %v, want %v", got, io.ErrUnexpectedEOF)
+	}
+	if got := Find(err, func(err error) bool { return Code(err) == CodeNotFound }); got.Error() != "unexpected EOF" {
+		t.Errorf("Find(CodeNotFound): got %#v, want the error with the code", got)
+	}
+	if got := Find(err, func(err error) bool { return err == io.EOF }); got != nil {
+		t.Errorf("Find(io.EOF): got %v, want nil", got)
+	}
+}